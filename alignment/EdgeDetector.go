@@ -0,0 +1,363 @@
+
+package alignment
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"image"
+	"image/color"
+	"sort"
+
+	"github.com/twolfe18/sudoku/debugsink"
+	"github.com/twolfe18/sudoku/drawing"
+	"github.com/twolfe18/sudoku/geometry"
+	"github.com/twolfe18/sudoku/imaging"
+	"github.com/twolfe18/sudoku/logging"
+)
+
+var log = logging.For("alignment")
+
+const (
+	SudokuGridDimension = 9	// side of board (in squares, not lines)
+)
+
+// an EdgeDetector is a value. nothing writes to lines after it is built,
+// anything that moves them (Proposal) works on a CloneEdgeDetector, which
+// has its own copy. the one exception is rng: clones share it, so every
+// draw of a run comes from params.Seed in order, and one EdgeDetector (or
+// its clones) can't be used from several goroutines at once.
+type EdgeDetector struct {
+	lines []geometry.Line
+	params EdgeDetectorParams
+	rng *rand.Rand
+
+	// starts at params.ProposalVariance, may be annealed during AlignTo
+	proposal_variance float64
+}
+
+// what AlignTo hands to a debug sink after each iteration
+type AlignStep struct {
+	Iteration int `json:"iteration"`
+	Accepted int `json:"accepted"`
+	Potentials []float64 `json:"potentials"`	// raw, as returned by Potential
+	LogPriors []float64 `json:"log_priors"`	// of each proposal's step, see LogPrior
+	Terms []PotentialTerms `json:"terms"`	// Potentials broken down
+	Weights []float64 `json:"weights"`	// what WeightedChoice saw
+	Proposals [][]geometry.Line `json:"proposals"`
+}
+
+// debug may be nil. when it is enabled each iteration leaves an overlay of
+// the accepted lattice (align.<iter>) and its AlignStep (align.<iter>.proposals)
+func (ed EdgeDetector) AlignTo(img image.Image, debug debugsink.Sink) (EdgeDetector, error) {
+	// TODO i can just impelment each of these and see which is fastest (all derivative free)
+	// option 1: draw K transforms, take the best point
+	// option 2: draw K transforms, take the best point and do line search
+	// option 3: draw K transforms, if best point isn't "good enough" then drak K more _smaller_ transforms
+	debug = debugsink.OrNop(debug)
+	bounds := geometry.NewFloat64Rectangle(img.Bounds())
+	cur_ed := ed
+	for iter := 0; iter < ed.params.NumIterations; iter++ {
+
+		// propose some new edge detector positions
+		proposals := make([]EdgeDetector, ed.params.NumProposals)
+		potentials := make([]float64, ed.params.NumProposals)
+		priors := make([]float64, ed.params.NumProposals)
+		terms := make([]PotentialTerms, ed.params.NumProposals)
+		for i := uint(0); i < cur_ed.params.NumProposals; i++ {
+			var s Step
+			proposals[i], s = cur_ed.Proposal(bounds)
+			terms[i] = proposals[i].PotentialTerms(img)
+			potentials[i] = terms[i].Total
+			priors[i] = cur_ed.params.LogPrior(s, cur_ed.proposal_variance)
+		}
+		var raw []float64
+		if debug.Enabled() { raw = append(raw, potentials...) }
+		for i,_ := range potentials {
+			potentials[i] += ed.params.PriorWeight * priors[i]
+		}
+		greedyWeights(potentials, ed.params.Greedyness)
+
+		i, err := WeightedChoice(cur_ed.rng, potentials)
+		if err != nil {
+			return cur_ed, fmt.Errorf("[EdgeDetector.AlignTo] iteration %d: %w", iter, err)
+		}
+		cur_ed = proposals[i]
+		t := terms[i]
+		log.Debug("accepted proposal", "iteration", iter, "proposal", i, "weight", potentials[i], "potential", t.Total,
+			"data", t.Data, "cross", t.Cross, "parallel", t.Parallel, "orthogonal", t.Orthogonal, "spacing", t.Spacing)
+
+		// test this on images to see how fast this should be decreased
+		//cur_ed.proposal_variance *= 0.9
+
+		if debug.Enabled() {
+			step := AlignStep{Iteration: iter, Accepted: i, Potentials: raw, LogPriors: priors, Terms: terms, Weights: potentials}
+			for _,p := range proposals {
+				step.Proposals = append(step.Proposals, p.Lines())
+			}
+			name := fmt.Sprintf("align.%03d", iter)
+			if debugsink.WantsImages(debug) {
+				if err = debug.Image(name, cur_ed.Draw(img)); err != nil {
+					return cur_ed, err
+				}
+			}
+			if err = debug.Data(name + ".proposals", step); err != nil {
+				return cur_ed, err
+			}
+		}
+	}
+	return cur_ed, nil
+}
+
+func NewEdgeDetector(b geometry.Float64Rectangle, p EdgeDetectorParams) EdgeDetector {
+	ed := new(EdgeDetector)
+	ed.params = p
+	ed.rng = rand.New(rand.NewSource(p.Seed))
+	ed.proposal_variance = p.ProposalVariance
+
+	// place some lines
+	padding := p.Padding
+	num_lines := p.NumLines
+	dx := (b.Dx() - 2.0*padding) / float64(num_lines - 1)
+	dy := (b.Dx() - 2.0*padding) / float64(num_lines - 1)
+	x0 := b.Min.X + padding; xmax := b.Max.X - padding; x := x0
+	y0 := b.Min.Y + padding; ymax := b.Max.Y - padding; y := y0
+	//fmt.Printf("[NewEdgeDetector] x0 = %.2f, y0 = %.2f, xmax = %.2f, ymax = %.2f\n", x0, y0, xmax, ymax)
+	for i := 0; i < num_lines; i++ {
+		v := geometry.Line{Left: geometry.Float64Point{X: x, Y: y0}, Right: geometry.Float64Point{X: x, Y: ymax}, Radius: p.LineRadius}	// vertical
+		h := geometry.Line{Left: geometry.Float64Point{X: x0, Y: y}, Right: geometry.Float64Point{X: xmax, Y: y}, Radius: p.LineRadius}	// horizontal
+		ed.lines = append(ed.lines, v)
+		ed.lines = append(ed.lines, h)
+		x += dx; y += dy
+		//fmt.Printf("[NewEdgeDetector] v = %s, h = %s\n", v.String(), h.String())
+	}
+	//fmt.Printf("[ned] ed.lines = %s\n", ed.lines)
+
+	// random perturbation of "perfect"
+	if p.Crappyness == 0.0 {
+		return *ed
+	}
+	ed.proposal_variance *= p.Crappyness
+	n, _ := ed.Proposal(b)
+	n.proposal_variance = p.ProposalVariance
+	return n
+}
+
+// a copy of every line, in the order NewEdgeDetector placed them
+func (ed EdgeDetector) Lines() []geometry.Line {
+	return append([]geometry.Line(nil), ed.lines...)
+}
+
+// lines are placed in (vertical, horizontal) pairs by NewEdgeDetector
+func (ed EdgeDetector) IsVertical(i int) bool {
+	return i % 2 == 0
+}
+
+type byMidpoint struct {
+	lines []geometry.Line
+	vertical bool
+}
+
+func (b byMidpoint) Len() int { return len(b.lines) }
+func (b byMidpoint) Swap(i, j int) { b.lines[i], b.lines[j] = b.lines[j], b.lines[i] }
+func (b byMidpoint) Less(i, j int) bool {
+	if b.vertical {
+		return b.lines[i].Midpoint().X < b.lines[j].Midpoint().X
+	}
+	return b.lines[i].Midpoint().Y < b.lines[j].Midpoint().Y
+}
+
+// the vertical (left to right) or horizontal (top to bottom) lines
+func (ed EdgeDetector) Family(vertical bool) (fam []geometry.Line) {
+	for i, l := range ed.lines {
+		if ed.IsVertical(i) == vertical {
+			fam = append(fam, l)
+		}
+	}
+	sort.Sort(byMidpoint{fam, vertical})
+	return fam
+}
+
+// the corners of the board in the order top-left, top-right, bottom-right,
+// bottom-left, found by intersecting the outermost lines of each direction
+func (ed EdgeDetector) Corners() (c [4]geometry.Float64Point) {
+	v := ed.Family(true)
+	h := ed.Family(false)
+	if len(v) == 0 || len(h) == 0 {
+		return c
+	}
+	c[0], _ = geometry.Intersection(h[0], v[0])
+	c[1], _ = geometry.Intersection(h[0], v[len(v)-1])
+	c[2], _ = geometry.Intersection(h[len(h)-1], v[len(v)-1])
+	c[3], _ = geometry.Intersection(h[len(h)-1], v[0])
+	return c
+}
+
+// the cell at row r, column c. with a full lattice this comes from the lines
+// around the cell, otherwise the board is split evenly between the corners.
+func (ed EdgeDetector) CellQuad(r, c int) geometry.Polygon {
+	v := ed.Family(true)
+	h := ed.Family(false)
+	if len(v) != SudokuGridDimension + 1 || len(h) != SudokuGridDimension + 1 {
+		return BilinearCell(ed.Corners(), r, c)
+	}
+	tl, _ := geometry.Intersection(h[r], v[c])
+	tr, _ := geometry.Intersection(h[r], v[c+1])
+	br, _ := geometry.Intersection(h[r+1], v[c+1])
+	bl, _ := geometry.Intersection(h[r+1], v[c])
+	return geometry.Polygon{tl, tr, br, bl}
+}
+
+// a copy that shares nothing with ed, so changing its lines leaves ed alone
+func (ed EdgeDetector) CloneEdgeDetector() EdgeDetector {
+	e := new(EdgeDetector)
+	e.params = ed.params
+	e.rng = ed.rng
+	e.proposal_variance = ed.proposal_variance
+	e.lines = ed.Lines()
+	return *e
+}
+
+// a random move of the whole lattice. the shared part comes from
+// params.ProposalMode and is returned so AlignTo can score it with LogPrior.
+func (ed EdgeDetector) Proposal(bounds geometry.Float64Rectangle) (EdgeDetector, Step) {
+
+	if ed.params.ProposalMode == ProposalPerLine {
+		return ed.perLineProposal(bounds)
+	}
+	new_ed := ed.CloneEdgeDetector()
+
+	// rotations, shifts and stretches must be correlated
+	independent_scale := ed.params.IndependentScale
+	v := ed.params.Variance
+	step := ed.params.drawStep(ed.rng, ed.proposal_variance)
+	mean_theta := step.Theta * math.Pi / 180.0
+
+	// stretch about the center of all lines, so if the initial spacing is
+	// wrong it can be fixed
+	var center geometry.Float64Point
+	for _, l := range ed.lines {
+		m := l.Midpoint()
+		center.X += m.X / float64(len(ed.lines))
+		center.Y += m.Y / float64(len(ed.lines))
+	}
+	stretch := func(p geometry.Float64Point) geometry.Float64Point {
+		return geometry.Float64Point{
+			X: center.X + (p.X - center.X) * (1.0 + step.ScaleX),
+			Y: center.Y + (p.Y - center.Y) * (1.0 + step.ScaleY),
+		}
+	}
+
+	for i, l := range ed.lines {
+
+		nl := *new(geometry.Line)	// new line
+		nl.Radius = l.Radius
+
+		// first rotate the line
+		theta := mean_theta + independent_scale * uniform(ed.rng, v.Rotate * ed.proposal_variance) * math.Pi / 180.0
+		z := geometry.PointMinus(l.Right, l.Left)
+		z.Rotate(theta)
+
+		// scale back up to the correct length
+		// new and old vecs share a midpoint, add/subtract half of the difference
+		z.Scale(0.5)
+		nl.Left = geometry.PointMinus(l.Midpoint(), z)
+		nl.Right = geometry.PointPlus(l.Midpoint(), z)
+
+		// then shrink or stretch the whole lattice
+		nl.Left = stretch(nl.Left)
+		nl.Right = stretch(nl.Right)
+
+		// now apply left-right and up-down shifts. badly spaced lines are
+		// left to the per_line mode, this only jitters.
+		dx := step.DX + independent_scale * uniform(ed.rng, v.ShiftX * ed.proposal_variance)	// left-right movement
+		dy := step.DY + independent_scale * uniform(ed.rng, v.ShiftY * ed.proposal_variance)	// up-down movement
+		nl.Shift(dx, dy)
+
+		// now make sure it's in the bounds
+		nl.ProjectInto(bounds)
+		new_ed.lines[i] = nl
+	}
+
+	return new_ed, step
+}
+
+// slides each line along its normal by its own amount, so every line keeps
+// its angle but the gaps between them can grow or shrink one at a time
+func (ed EdgeDetector) perLineProposal(bounds geometry.Float64Rectangle) (EdgeDetector, Step) {
+	new_ed := ed.CloneEdgeDetector()
+	max := ed.params.Variance.Normal * ed.proposal_variance
+	var step Step
+	for i, l := range ed.lines {
+		d := uniform(ed.rng, max)
+		u := unit(l)
+		nl := l
+		nl.Shift(-u.Y * d, u.X * d)
+		nl.ProjectInto(bounds)
+		new_ed.lines[i] = nl
+		step.Normal = append(step.Normal, d)
+	}
+	return new_ed, step
+}
+
+var (
+	lineColor = color.RGBA{255, 0, 0, 255}
+	cornerColor = color.RGBA{0, 200, 0, 255}
+)
+
+// a copy of img with the lattice over it and the board corners marked
+func (ed EdgeDetector) Draw(img image.Image) image.Image {
+	log.Debug("drawing", "lines", len(ed.lines), "width", img.Bounds().Dx(), "height", img.Bounds().Dy())
+	output := imaging.CopyImage(img)
+	for _, l := range ed.lines {
+		drawing.Line(output, l, 1.5, lineColor)
+	}
+	for _, c := range ed.Corners() {
+		drawing.Circle(output, c, 3.0, cornerColor)
+	}
+	return output
+}
+
+// how much dark ink sits under each line, the data term of Potential.
+// pixels near the segment count with Line.WeightedIterator's weights, the
+// same as curveInk, so a straight CurvedGrid scores what its EdgeDetector did.
+func (ed EdgeDetector) LinePotentials(img image.Image) []float64 {
+	pots := make([]float64, len(ed.lines))
+	for i, line := range ed.lines {
+		for _, wp := range line.WeightedIterator(img.Bounds()) {
+			pots[i] += imaging.DarknessAt(img, wp.P.X, wp.P.Y) * wp.W
+		}
+		if math.IsInf(pots[i], 1) {
+			log.Error("potential hit inf", "line", line.String())
+			panic("[EdgeDetector.Potential] hit inf")
+		}
+	}
+	return pots
+}
+
+// the lattice as vectors over img, each line labeled with its index and
+// LinePotential and the corners marked. img is embedded unless href is given.
+func (ed EdgeDetector) SVG(img image.Image, href string) *drawing.SVG {
+	s := drawing.NewSVG(img.Bounds())
+	if href == "" {
+		s.Image = img
+	} else {
+		s.ImageHref = href
+	}
+	for i, pot := range ed.LinePotentials(img) {
+		s.AddLine(ed.lines[i], 1.0, lineColor, fmt.Sprintf("%d: %.1f", i, pot))
+	}
+	for _, c := range ed.Corners() {
+		s.AddPoint(c, 3.0, cornerColor, "")
+	}
+	return s
+}
+
+// how well the lattice fits img, bigger is better. the "sparse prior" on
+// random steps lives in the proposals, see ProposalMode and LogPrior.
+func (ed EdgeDetector) Potential(img image.Image) float64 {
+	t := ed.PotentialTerms(img)
+	log.Debug("potential", "potential", t.Total, "data", t.Data, "cross", t.Cross,
+		"parallel", t.Parallel, "orthogonal", t.Orthogonal, "spacing", t.Spacing)
+	return t.Total
+}
@@ -0,0 +1,214 @@
+package alignment
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"math"
+	"testing"
+
+	"github.com/twolfe18/sudoku/debugsink"
+	"github.com/twolfe18/sudoku/geometry"
+)
+
+// a small white image with a dark 4x4 grid on it
+func gridImage(size int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			c := color.Gray{255}
+			if x % (size / 4) == 0 || y % (size / 4) == 0 { c = color.Gray{0} }
+			img.SetGray(x, y, c)
+		}
+	}
+	return img
+}
+
+func smallParams() EdgeDetectorParams {
+	p := DefaultEdgeDetectorParams()
+	p.NumProposals = 3
+	p.NumIterations = 2
+	p.Padding = 4
+	return p
+}
+
+func TestAlignToDebugSink(t *testing.T) {
+	img := gridImage(32)
+	p := smallParams()
+	ed := NewEdgeDetector(geometry.NewFloat64Rectangle(img.Bounds()), p)
+	mem := debugsink.NewMemory()
+	if _, err := ed.AlignTo(img, mem); err != nil {
+		t.Fatal(err)
+	}
+	for iter := 0; iter < p.NumIterations; iter++ {
+		name := fmt.Sprintf("align.%03d", iter)
+		if _, ok := mem.Images[name]; !ok {
+			t.Errorf("no overlay %s, got %v", name, mem.Names)
+		}
+		step, ok := mem.Values[name + ".proposals"].(AlignStep)
+		if !ok {
+			t.Errorf("no proposals for %s, got %v", name, mem.Names)
+			continue
+		}
+		if step.Iteration != iter || len(step.Potentials) != int(p.NumProposals) || len(step.Proposals) != int(p.NumProposals) {
+			t.Errorf("bad step %d: %+v", iter, step)
+		}
+		if step.Accepted < 0 || step.Accepted >= len(step.Proposals) {
+			t.Errorf("accepted proposal %d out of range", step.Accepted)
+		}
+	}
+}
+
+func TestAlignToNilSink(t *testing.T) {
+	img := gridImage(32)
+	ed := NewEdgeDetector(geometry.NewFloat64Rectangle(img.Bounds()), smallParams())
+	if _, err := ed.AlignTo(img, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAnimation(t *testing.T) {
+	// big enough, and padded enough, that the label in the corner can't
+	// cover the middle of any line
+	img := gridImage(128)
+	p := smallParams()
+	p.Padding = 24
+	p.Crappyness = 0.0
+	ed := NewEdgeDetector(geometry.NewFloat64Rectangle(img.Bounds()), p)
+	anim := NewAnimation(img)
+	mem := debugsink.NewMemory()
+	if _, err := ed.AlignTo(img, debugsink.Tee{mem, anim}); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := anim.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	g, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Image) != p.NumIterations {
+		t.Fatalf("%d frames for %d iterations", len(g.Image), p.NumIterations)
+	}
+
+	// the label should show up, and the accepted lattice be drawn over the
+	// middle of each of its lines
+	rgba := func(x, y int) color.RGBA {
+		r, gg, b, a := g.Image[0].At(x, y).RGBA()
+		return color.RGBA{uint8(r >> 8), uint8(gg >> 8), uint8(b >> 8), uint8(a >> 8)}
+	}
+	seen := false
+	for x := 0; x < 32; x++ {
+		seen = seen || rgba(x, 2) == labelColor
+	}
+	if !seen {
+		t.Errorf("frame is missing the label")
+	}
+	step := mem.Values["align.000.proposals"].(AlignStep)
+	for i, l := range step.Proposals[step.Accepted] {
+		m := l.Midpoint()
+		if c := rgba(int(math.Round(m.X)), int(math.Round(m.Y))); c != acceptedColor {
+			t.Errorf("accepted line %d %s is %v at its middle", i, l, c)
+		}
+	}
+}
+
+func TestAnimationEmpty(t *testing.T) {
+	if err := NewAnimation(gridImage(8)).Encode(&bytes.Buffer{}); err == nil {
+		t.Errorf("encoding zero frames should fail")
+	}
+}
+
+func TestSVGLabelsEveryLine(t *testing.T) {
+	img := gridImage(32)
+	ed := NewEdgeDetector(geometry.NewFloat64Rectangle(img.Bounds()), smallParams())
+	s := ed.SVG(img, "board.png")
+	if len(s.Lines) != len(ed.Lines()) || s.ImageHref != "board.png" || s.Image != nil {
+		t.Fatalf("%d svg lines for %d lines, href %q", len(s.Lines), len(ed.Lines()), s.ImageHref)
+	}
+	pots := ed.LinePotentials(img)
+	for i, l := range s.Lines {
+		if want := fmt.Sprintf("%d: %.1f", i, pots[i]); l.Label != want || !l.Line.Equals(ed.Lines()[i]) {
+			t.Errorf("line %d labeled %q, want %q", i, l.Label, want)
+		}
+	}
+}
+
+func TestProposalsDontShareLines(t *testing.T) {
+	// no perturbation and lots of padding, so nothing starts against the
+	// border where ProjectInto would clamp every shift to the same place
+	p := DefaultEdgeDetectorParams()
+	p.Padding = 16
+	p.Crappyness = 0.0
+	b := geometry.NewFloat64Rectangle(image.Rect(0, 0, 64, 64))
+	for _, m := range ProposalModes {
+		p.ProposalMode = m
+		ed := NewEdgeDetector(b, p)
+		before := ed.Lines()
+
+		// as AlignTo does, then check nothing changed after the fact
+		proposals := make([]EdgeDetector, 75)
+		drawn := make([][]geometry.Line, len(proposals))
+		for i := range proposals {
+			proposals[i], _ = ed.Proposal(b)
+			drawn[i] = proposals[i].Lines()
+		}
+		for i, l := range ed.lines {
+			if !l.Equals(before[i]) {
+				t.Fatalf("%s: proposing moved line %d of the current lattice from %s to %s", m, i, before[i], l)
+			}
+		}
+		for i, prop := range proposals {
+			for j, l := range prop.lines {
+				if !l.Equals(drawn[i][j]) {
+					t.Fatalf("%s: proposal %d line %d was overwritten by a later proposal", m, i, j)
+				}
+			}
+		}
+		same := true
+		for j := range drawn[0] {
+			same = same && drawn[0][j].Equals(drawn[1][j])
+		}
+		if same {
+			t.Errorf("%s: two proposals came out the same", m)
+		}
+	}
+}
+
+func TestCloneEdgeDetector(t *testing.T) {
+	ed := NewEdgeDetector(geometry.NewFloat64Rectangle(image.Rect(0, 0, 64, 64)), smallParams())
+	c := ed.CloneEdgeDetector()
+	c.lines[0].Shift(5.0, 5.0)
+	if c.lines[0].Equals(ed.lines[0]) {
+		t.Errorf("the clone's lines are still the original's")
+	}
+}
+
+func TestSeedRepeats(t *testing.T) {
+	img := gridImage(64)
+	p := smallParams()
+	run := func(seed int64) []geometry.Line {
+		p.Seed = seed
+		ed, err := NewEdgeDetector(geometry.NewFloat64Rectangle(img.Bounds()), p).AlignTo(img, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ed.Lines()
+	}
+	a, b, c := run(3), run(3), run(4)
+	same := func(x, y []geometry.Line) bool {
+		for i := range x {
+			if !x[i].Equals(y[i]) { return false }
+		}
+		return true
+	}
+	if !same(a, b) {
+		t.Errorf("the same seed aligned differently")
+	}
+	if same(a, c) {
+		t.Errorf("a different seed aligned the same")
+	}
+}
@@ -0,0 +1,120 @@
+package alignment
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"os"
+
+	"github.com/twolfe18/sudoku/drawing"
+	"github.com/twolfe18/sudoku/imaging"
+)
+
+var (
+	rejectedColor = color.RGBA{110, 150, 255, 255}
+	acceptedColor = color.RGBA{255, 0, 0, 255}
+	labelColor = color.RGBA{255, 230, 0, 255}
+)
+
+// grays for the board plus the overlay colors
+var animationPalette = func() color.Palette {
+	p := make(color.Palette, 0, 256)
+	for i := 0; i < 240; i++ {
+		v := uint8(i * 255 / 239)
+		p = append(p, color.Gray{v})
+	}
+	return append(p, rejectedColor, acceptedColor, labelColor)
+}()
+
+// Animation turns an alignment run into an animated GIF. hand it to AlignTo
+// as a debug sink (alone or in a debugsink.Tee), it makes one frame per
+// iteration out of the AlignSteps and ignores everything else. each frame
+// has the rejected proposals in a faint color, the accepted lattice in bold,
+// and the iteration and its Potential in the corner.
+type Animation struct {
+	img image.Image
+	frames []*image.Paletted
+	Delay int	// per frame, in 100ths of a second
+}
+
+func NewAnimation(img image.Image) *Animation {
+	return &Animation{img: img, Delay: 25}
+}
+
+func (a *Animation) Enabled() bool { return true }
+
+// frames are drawn from the AlignSteps, so AlignTo needn't render overlays
+func (a *Animation) WantsImages() bool { return false }
+
+func (a *Animation) Image(name string, img image.Image) error { return nil }
+
+func (a *Animation) Data(name string, v interface{}) error {
+	if step, ok := v.(AlignStep); ok {
+		a.frames = append(a.frames, a.frame(step))
+	}
+	return nil
+}
+
+func (a *Animation) Len() int { return len(a.frames) }
+
+func (a *Animation) frame(step AlignStep) *image.Paletted {
+	rgba := imaging.CopyImage(a.img)
+	for i, lines := range step.Proposals {
+		if i == step.Accepted { continue }
+		for _, l := range lines {
+			drawing.Line(rgba, l, 1.0, rejectedColor)
+		}
+	}
+	if step.Accepted >= 0 && step.Accepted < len(step.Proposals) {
+		for _, l := range step.Proposals[step.Accepted] {
+			drawing.Line(rgba, l, 3.0, acceptedColor)
+		}
+	}
+
+	pot := 0.0
+	if step.Accepted >= 0 && step.Accepted < len(step.Potentials) {
+		pot = step.Potentials[step.Accepted]
+	}
+	scale := 1 + rgba.Bounds().Dx() / 400
+	b := rgba.Bounds()
+	label := fmt.Sprintf("ITER %d POT %.1f", step.Iteration, pot)
+	drawing.Font5x7.Label(rgba, label, b.Min.X, b.Min.Y, scale, 2 * scale, labelColor, color.Black)
+
+	out := image.NewPaletted(b, animationPalette)
+	draw.Draw(out, b, rgba, b.Min, draw.Src)
+	return out
+}
+
+func (a *Animation) Encode(w io.Writer) error {
+	if len(a.frames) == 0 {
+		return fmt.Errorf("[Animation.Encode] no frames, was the animation passed to AlignTo?")
+	}
+	anim := &gif.GIF{}
+	for _, f := range a.frames {
+		anim.Image = append(anim.Image, f)
+		anim.Delay = append(anim.Delay, a.Delay)
+	}
+	// hold the last frame so the final answer is visible
+	anim.Delay[len(anim.Delay) - 1] = 4 * a.Delay
+	return gif.EncodeAll(w, anim)
+}
+
+// writes the animation to outf, replacing anything already there
+func (a *Animation) Save(outf string) (err error) {
+	writer, err := os.Create(outf)
+	if err != nil {
+		return fmt.Errorf("[Animation.Save] could not open %s: %w", outf, err)
+	}
+	defer func() {
+		if cerr := writer.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("[Animation.Save] could not close %s: %w", outf, cerr)
+		}
+	}()
+	if err = a.Encode(writer); err != nil {
+		return fmt.Errorf("[Animation.Save] problem saving to %s: %w", outf, err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package alignment
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/twolfe18/sudoku/geometry"
+)
+
+// ground truth for one board image, stored next to it as <image>.json:
+//
+//	{
+//		"corners": [{"X": 12, "Y": 10}, {"X": 240, "Y": 14}, {"X": 236, "Y": 242}, {"X": 9, "Y": 238}],
+//		"cells": [5, 3, 0, 0, 7, 0, 0, 0, 0, 6, ...]
+//	}
+//
+// corners are in pixels and ordered top-left, top-right, bottom-right, bottom-left
+// as seen in the image. cells are the 81 values in row major order, 0 for blank.
+type Annotation struct {
+	Corners [4]geometry.Float64Point `json:"corners"`
+	Cells []int `json:"cells"`
+}
+
+func AnnotationPath(img_path string) string {
+	return img_path[:len(img_path) - len(filepath.Ext(img_path))] + ".json"
+}
+
+func LoadAnnotation(path string) (a Annotation, err error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return a, fmt.Errorf("[LoadAnnotation] could not read %s: %s", path, err)
+	}
+	if err = json.Unmarshal(buf, &a); err != nil {
+		return a, fmt.Errorf("[LoadAnnotation] could not parse %s: %s", path, err)
+	}
+	if err = a.Validate(); err != nil {
+		return a, fmt.Errorf("[LoadAnnotation] %s: %s", path, err)
+	}
+	return a, nil
+}
+
+func (a Annotation) Save(path string) error {
+	buf, err := json.MarshalIndent(a, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf, 0644)
+}
+
+func (a Annotation) Validate() error {
+	n := SudokuGridDimension * SudokuGridDimension
+	if len(a.Cells) != n {
+		return fmt.Errorf("expected %d cells, got %d", n, len(a.Cells))
+	}
+	for i, v := range a.Cells {
+		if v < 0 || v > SudokuGridDimension {
+			return fmt.Errorf("cell (%d, %d) = %d is not in [0, %d]", i / SudokuGridDimension, i % SudokuGridDimension, v, SudokuGridDimension)
+		}
+	}
+	// every three corners of a quad are consecutive, if any are in a line
+	// the board has no shape
+	for i := range a.Corners {
+		prev, next := a.Corners[(i + 3) % 4], a.Corners[(i + 1) % 4]
+		if (geometry.Polygon{prev, a.Corners[i], next}).Area() < 1e-6 {
+			return fmt.Errorf("corners %s: %s, %s and %s are in a line", a.Corners, prev, a.Corners[i], next)
+		}
+	}
+	return nil
+}
+
+// the cell at row r, column c as a quad, by bilinear interpolation of the corners
+func (a Annotation) CellQuad(r, c int) geometry.Polygon {
+	return BilinearCell(a.Corners, r, c)
+}
+
+func BilinearCell(corners [4]geometry.Float64Point, r, c int) geometry.Polygon {
+	at := func(u, v float64) geometry.Float64Point {
+		// u goes left to right, v top to bottom
+		top := geometry.PointPlus(corners[0], scaled(geometry.PointMinus(corners[1], corners[0]), u))
+		bottom := geometry.PointPlus(corners[3], scaled(geometry.PointMinus(corners[2], corners[3]), u))
+		return geometry.PointPlus(top, scaled(geometry.PointMinus(bottom, top), v))
+	}
+	n := float64(SudokuGridDimension)
+	u0, u1 := float64(c) / n, float64(c+1) / n
+	v0, v1 := float64(r) / n, float64(r+1) / n
+	return geometry.Polygon{at(u0, v0), at(u1, v0), at(u1, v1), at(u0, v1)}
+}
+
+func scaled(p geometry.Float64Point, s float64) geometry.Float64Point {
+	p.Scale(s)
+	return p
+}
@@ -0,0 +1,84 @@
+package alignment
+
+import (
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/twolfe18/sudoku/geometry"
+)
+
+func squareAnnotation() Annotation {
+	return Annotation{
+		Corners: [4]geometry.Float64Point{{X: 10, Y: 10}, {X: 100, Y: 10}, {X: 100, Y: 100}, {X: 10, Y: 100}},
+		Cells: make([]int, SudokuGridDimension * SudokuGridDimension),
+	}
+}
+
+func TestAnnotationValidate(t *testing.T) {
+	if err := squareAnnotation().Validate(); err != nil {
+		t.Fatalf("a square board: %s", err)
+	}
+	short := squareAnnotation()
+	short.Cells = short.Cells[:80]
+	big := squareAnnotation()
+	big.Cells[40] = 10
+	flat := squareAnnotation()
+	flat.Corners = [4]geometry.Float64Point{{X: 0, Y: 0}, {X: 10, Y: 10}, {X: 20, Y: 20}, {X: 30, Y: 30}}
+	triangle := squareAnnotation()
+	triangle.Corners[1] = geometry.Float64Point{X: 55, Y: 55}	// on the diagonal from 0 to 2
+	for name, c := range map[string]struct {
+		a Annotation
+		want string
+	}{
+		"80 cells": {short, "expected 81 cells, got 80"},
+		"a 10": {big, "cell (4, 4) = 10"},
+		"all corners in a line": {flat, "in a line"},
+		"three corners in a line": {triangle, "in a line"},
+	} {
+		if err := c.a.Validate(); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: got %v, want %q", name, err, c.want)
+		}
+	}
+}
+
+func TestBilinearCell(t *testing.T) {
+	a := squareAnnotation()	// 90 pixels, 10 per cell
+	want := geometry.Polygon{{X: 30, Y: 20}, {X: 40, Y: 20}, {X: 40, Y: 30}, {X: 30, Y: 30}}
+	for i, p := range a.CellQuad(1, 2) {
+		if math.Abs(p.X - want[i].X) > 1e-9 || math.Abs(p.Y - want[i].Y) > 1e-9 {
+			t.Errorf("cell (1, 2) corner %d at %s, want %s", i, p, want[i])
+		}
+	}
+	// on any quad the cells tile the board
+	a.Corners = [4]geometry.Float64Point{{X: 12, Y: 8}, {X: 95, Y: 15}, {X: 105, Y: 98}, {X: 5, Y: 90}}
+	sum := 0.0
+	for r := 0; r < SudokuGridDimension; r++ {
+		for c := 0; c < SudokuGridDimension; c++ {
+			sum += a.CellQuad(r, c).Area()
+		}
+	}
+	if board := geometry.Polygon(a.Corners[:]).Area(); math.Abs(sum - board) > 1e-6 {
+		t.Errorf("cells cover %.3f of a %.3f board", sum, board)
+	}
+}
+
+func TestAnnotationSaveLoad(t *testing.T) {
+	a := squareAnnotation()
+	a.Cells[0] = 5
+	path := filepath.Join(t.TempDir(), "board.json")
+	if err := a.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	b, err := LoadAnnotation(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Corners != a.Corners || b.Cells[0] != 5 {
+		t.Errorf("came back as %+v", b)
+	}
+	if AnnotationPath("img/board.png") != "img/board.json" {
+		t.Errorf("annotation path %s", AnnotationPath("img/board.png"))
+	}
+}
@@ -0,0 +1,97 @@
+package alignment
+
+import (
+	"image"
+	"math"
+
+	"github.com/twolfe18/sudoku/geometry"
+	"github.com/twolfe18/sudoku/imaging"
+)
+
+// where a horizontal line h crosses a vertical line v
+type crossing struct {
+	p geometry.Float64Point
+	h, v geometry.Line
+}
+
+func (ed EdgeDetector) crossings(bounds image.Rectangle) (cs []crossing) {
+	b := geometry.NewFloat64Rectangle(bounds)
+	for _, h := range ed.Family(false) {
+		for _, v := range ed.Family(true) {
+			p, ok := geometry.Intersection(h, v)
+			if ok && p.X >= b.Min.X && p.Y >= b.Min.Y && p.X < b.Max.X && p.Y < b.Max.Y {
+				cs = append(cs, crossing{p, h, v})
+			}
+		}
+	}
+	return cs
+}
+
+// every point where a vertical line crosses a horizontal one and that
+// lands inside bounds, (NumLines)^2 of them for a full lattice
+func (ed EdgeDetector) Intersections(bounds image.Rectangle) (pts []geometry.Float64Point) {
+	for _, c := range ed.crossings(bounds) {
+		pts = append(pts, c.p)
+	}
+	return pts
+}
+
+// darkness at the nearest pixel, ok is false off the image
+func darknessNear(img image.Image, p geometry.Float64Point) (float64, bool) {
+	pt := image.Pt(int(math.Round(p.X)), int(math.Round(p.Y)))
+	if !pt.In(img.Bounds()) {
+		return 0.0, false
+	}
+	return imaging.DarknessAt(img, pt.X, pt.Y), true
+}
+
+func unit(l geometry.Line) geometry.Float64Point {
+	d := geometry.PointMinus(l.Right, l.Left)
+	if n := d.L2Norm(); n > 0.0 {
+		d.Scale(1.0 / n)
+	}
+	return d
+}
+
+// how cross shaped the image is at p, for a cross with arms along u and v
+// reaching arm pixels out: mean darkness on the arms minus mean darkness in
+// the four quadrants between them. in [-1, 1], 0 for flat regions, and
+// near 1 for a dark cross on white paper.
+func crossAt(img image.Image, p, u, v geometry.Float64Point, arm float64) float64 {
+	at := func(a, b float64) geometry.Float64Point {
+		return geometry.Float64Point{X: p.X + a * u.X + b * v.X, Y: p.Y + a * u.Y + b * v.Y}
+	}
+	on, on_n := 0.0, 0
+	off, off_n := 0.0, 0
+	for t := -arm; t <= arm; t += 1.0 {
+		for _, q := range []geometry.Float64Point{at(t, 0), at(0, t)} {
+			if d, ok := darknessNear(img, q); ok { on += d; on_n++ }
+		}
+	}
+	// the quadrants, kept a pixel away from the arms
+	for a := 2.0; a <= arm; a += 1.0 {
+		for b := 2.0; b <= arm; b += 1.0 {
+			for _, q := range []geometry.Float64Point{at(a, b), at(-a, b), at(a, -b), at(-a, -b)} {
+				if d, ok := darknessNear(img, q); ok { off += d; off_n++ }
+			}
+		}
+	}
+	if on_n == 0 || off_n == 0 {
+		return 0.0
+	}
+	return on / float64(on_n) - off / float64(off_n)
+}
+
+// mean cross response over the lattice intersections, each cross oriented
+// along the two lines that make it
+func (ed EdgeDetector) CrossScore(img image.Image) float64 {
+	cs := ed.crossings(img.Bounds())
+	if len(cs) == 0 {
+		return 0.0
+	}
+	total := 0.0
+	for _, c := range cs {
+		total += crossAt(img, c.p, unit(c.h), unit(c.v), ed.params.CrossArm)
+	}
+	return total / float64(len(cs))
+}
@@ -0,0 +1,66 @@
+package alignment
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/twolfe18/sudoku/geometry"
+)
+
+// a lattice with vertical and horizontal lines at each offset
+func lattice(p EdgeDetectorParams, size float64, at ...float64) EdgeDetector {
+	ed := EdgeDetector{params: p, rng: rand.New(rand.NewSource(p.Seed)), proposal_variance: p.ProposalVariance}
+	for _, o := range at {
+		ed.lines = append(ed.lines,
+			geometry.Line{Left: geometry.Float64Point{X: o, Y: 0}, Right: geometry.Float64Point{X: o, Y: size}, Radius: p.LineRadius},
+			geometry.Line{Left: geometry.Float64Point{X: 0, Y: o}, Right: geometry.Float64Point{X: size, Y: o}, Radius: p.LineRadius})
+	}
+	return ed
+}
+
+func TestCrossAt(t *testing.T) {
+	img := gridImage(32)	// dark lines every 8 pixels
+	u := geometry.Float64Point{X: 1, Y: 0}
+	v := geometry.Float64Point{X: 0, Y: 1}
+	if c := crossAt(img, geometry.Float64Point{X: 16, Y: 16}, u, v, 4); c < 0.9 {
+		t.Errorf("response %.2f on a crossing", c)
+	}
+	if c := crossAt(img, geometry.Float64Point{X: 12, Y: 12}, u, v, 3); c > 0.1 {
+		t.Errorf("response %.2f in the middle of a cell", c)
+	}
+	if c := crossAt(img, geometry.Float64Point{X: 16, Y: 12}, u, v, 3); c > 0.6 {
+		t.Errorf("response %.2f on a single line, should be well below a crossing", c)
+	}
+
+	flat := image.NewGray(image.Rect(0, 0, 16, 16))
+	for i := range flat.Pix { flat.Pix[i] = 200 }
+	if c := crossAt(flat, geometry.Float64Point{X: 8, Y: 8}, u, v, 4); c != 0.0 {
+		t.Errorf("response %.2f on a flat image", c)
+	}
+	flat.SetGray(0, 0, color.Gray{0})
+	if c := crossAt(flat, geometry.Float64Point{X: -20, Y: -20}, u, v, 4); c != 0.0 {
+		t.Errorf("response %.2f entirely off the image", c)
+	}
+}
+
+func TestCrossScoreRewardsAlignment(t *testing.T) {
+	img := gridImage(32)
+	p := smallParams()
+	on := lattice(p, 32, 8, 16, 24)
+	off := lattice(p, 32, 11.5, 19.5, 27.5)
+	if len(on.Intersections(img.Bounds())) != 9 {
+		t.Fatalf("expected 9 intersections, got %v", on.Intersections(img.Bounds()))
+	}
+	if a, b := on.CrossScore(img), off.CrossScore(img); a <= b + 0.5 {
+		t.Errorf("aligned lattice scores %.2f, misaligned %.2f", a, b)
+	}
+
+	// the term only reaches the potential when it has a weight
+	p.CrossWeight = 100.0
+	with := lattice(p, 32, 8, 16, 24)
+	if d := with.Potential(img) - on.Potential(img); d < 50.0 {
+		t.Errorf("cross_weight added only %.2f to the potential", d)
+	}
+}
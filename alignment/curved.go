@@ -0,0 +1,295 @@
+package alignment
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/twolfe18/sudoku/debugsink"
+	"github.com/twolfe18/sudoku/drawing"
+	"github.com/twolfe18/sudoku/geometry"
+	"github.com/twolfe18/sudoku/imaging"
+)
+
+// pixels per cell side in Rectify's output unless asked otherwise
+const RectifiedCell = 32
+
+// the lattice for folded newspapers and bowed book pages, where no straight
+// line fits. each line of an aligned EdgeDetector becomes a quadratic
+// Bézier with the same ends, and AlignTo only moves the control points, so
+// the straight fit decides where the board is and this decides how it bends.
+type CurvedGrid struct {
+	curves []geometry.Curve	// same order as the EdgeDetector's lines
+	params EdgeDetectorParams
+
+	// starts at params.ProposalVariance, like the EdgeDetector's
+	proposal_variance float64
+
+	// shared by copies, like the EdgeDetector's
+	rng *rand.Rand
+}
+
+// the curves start straight, on ed's lines. the grid draws from its own
+// generator, seeded from params.Seed, so bending doesn't depend on how
+// many draws the straight fit made.
+func NewCurvedGrid(ed EdgeDetector) CurvedGrid {
+	g := CurvedGrid{params: ed.params, proposal_variance: ed.params.ProposalVariance, rng: rand.New(rand.NewSource(ed.params.Seed))}
+	for _, l := range ed.lines {
+		g.curves = append(g.curves, geometry.StraightCurve(l))
+	}
+	return g
+}
+
+// a copy of every curve, in the order the EdgeDetector had its lines
+func (g CurvedGrid) Curves() []geometry.Curve {
+	return append([]geometry.Curve(nil), g.curves...)
+}
+
+func (g CurvedGrid) IsVertical(i int) bool {
+	return i % 2 == 0
+}
+
+// the curves of one direction sorted left to right or top to bottom by
+// their middles
+func (g CurvedGrid) Family(vertical bool) (fam []geometry.Curve) {
+	for i, c := range g.curves {
+		if g.IsVertical(i) == vertical {
+			fam = append(fam, c)
+		}
+	}
+	sort.Slice(fam, func(i, j int) bool {
+		if vertical {
+			return fam[i].At(0.5).X < fam[j].At(0.5).X
+		}
+		return fam[i].At(0.5).Y < fam[j].At(0.5).Y
+	})
+	return fam
+}
+
+// how much dark ink sits under c, weighted like EdgeDetector.LinePotentials
+func curveInk(img image.Image, c geometry.Curve) (ink float64) {
+	for _, wp := range c.WeightedIterator(img.Bounds()) {
+		ink += imaging.DarknessAt(img, wp.P.X, wp.P.Y) * wp.W
+	}
+	return ink
+}
+
+// how much dark ink sits under each curve, the data term of Potential
+func (g CurvedGrid) CurvePotentials(img image.Image) []float64 {
+	pots := make([]float64, len(g.curves))
+	for i, c := range g.curves {
+		pots[i] = curveInk(img, c)
+	}
+	return pots
+}
+
+func (g CurvedGrid) bendPenalty(c geometry.Curve) float64 {
+	return g.params.BendWeight * c.Bend() * c.Bend()
+}
+
+// ink under the curves less the bend penalty. the ends never move, so the
+// straight fit's geometric prior would be the same for every proposal and
+// is left out.
+func (g CurvedGrid) PotentialTerms(img image.Image) (t PotentialTerms) {
+	for _, p := range g.CurvePotentials(img) {
+		t.Data += p
+	}
+	t.Data /= float64(len(g.curves))
+	for _, c := range g.curves {
+		t.Bend += g.bendPenalty(c) / float64(len(g.curves))
+	}
+	t.Total = t.Data - t.Bend
+	return t
+}
+
+func (g CurvedGrid) Potential(img image.Image) float64 {
+	return g.PotentialTerms(img).Total
+}
+
+// curve i bent a random amount more or less
+func (g CurvedGrid) Proposal(i int) geometry.Curve {
+	c := g.curves[i]
+	c.SetBend(c.Bend() + uniform(g.rng, g.params.BendVariance * g.proposal_variance))
+	return c
+}
+
+// hill climbs bend_iterations times, like EdgeDetector.AlignTo but one
+// curve at a time: a curve's ink and bend penalty don't depend on the
+// others, so each picks from its own proposals. the current bend is always
+// one of them, so a bad batch can't undo what was found so far. debug may
+// be nil, when enabled each iteration leaves an overlay (bend.<iter>) and
+// its terms (bend.<iter>.terms).
+func (g CurvedGrid) AlignTo(img image.Image, debug debugsink.Sink) (CurvedGrid, error) {
+	debug = debugsink.OrNop(debug)
+	cur := g
+	cur.curves = g.Curves()
+	for iter := 0; iter < g.params.BendIterations; iter++ {
+		for c := range cur.curves {
+			proposals := []geometry.Curve{cur.curves[c]}
+			for i := uint(0); i < g.params.NumProposals; i++ {
+				proposals = append(proposals, cur.Proposal(c))
+			}
+			potentials := make([]float64, len(proposals))
+			for i, p := range proposals {
+				potentials[i] = curveInk(img, p) - g.bendPenalty(p)
+			}
+			i, err := WeightedChoice(cur.rng, greedyWeights(potentials, g.params.Greedyness))
+			if err != nil {
+				return cur, fmt.Errorf("[CurvedGrid.AlignTo] iteration %d, curve %d: %w", iter, c, err)
+			}
+			cur.curves[c] = proposals[i]
+		}
+		t := cur.PotentialTerms(img)
+		log.Debug("bent", "iteration", iter, "potential", t.Total, "data", t.Data, "bend", t.Bend)
+
+		if debug.Enabled() {
+			name := fmt.Sprintf("bend.%03d", iter)
+			if debugsink.WantsImages(debug) {
+				if err := debug.Image(name, cur.Draw(img)); err != nil {
+					return cur, err
+				}
+			}
+			if err := debug.Data(name + ".terms", t); err != nil {
+				return cur, err
+			}
+		}
+	}
+	return cur, nil
+}
+
+// where every horizontal curve meets every vertical one. p[j][i] is
+// h[j] crossing v[i], th and tv are how far along each curve that is.
+type curvedLattice struct {
+	v, h []geometry.Curve
+	p [][]geometry.Float64Point
+	th, tv [][]float64
+}
+
+func (g CurvedGrid) lattice() (lat curvedLattice) {
+	lat.v = g.Family(true)
+	lat.h = g.Family(false)
+	for _, h := range lat.h {
+		p := make([]geometry.Float64Point, len(lat.v))
+		th := make([]float64, len(lat.v))
+		tv := make([]float64, len(lat.v))
+		for i, v := range lat.v {
+			// parallel families are hopeless anyway, leave whatever came out
+			p[i], th[i], tv[i], _ = geometry.CurveIntersection(h, v)
+		}
+		lat.p = append(lat.p, p)
+		lat.th = append(lat.th, th)
+		lat.tv = append(lat.tv, tv)
+	}
+	return lat
+}
+
+func lerp(a, b, t float64) float64 { return a + (b - a) * t }
+
+// maps (u, v) in the unit square onto the board, u left to right and v top
+// to bottom. each cell of the lattice is a coons patch of the four curve
+// pieces around it, so cell edges follow the curves exactly and a straight
+// lattice gives the same quads as EdgeDetector.CellQuad. with fewer than
+// 10 lines a side the lattice cells are split evenly, as BilinearCell does.
+func (lat curvedLattice) At(u, v float64) geometry.Float64Point {
+	cell := func(x float64, n int) (int, float64) {
+		f := x * float64(n - 1)
+		k := min(max(int(math.Floor(f)), 0), n - 2)
+		return k, f - float64(k)
+	}
+	i, s := cell(u, len(lat.v))
+	j, t := cell(v, len(lat.h))
+	top := lat.h[j].At(lerp(lat.th[j][i], lat.th[j][i+1], s))
+	bottom := lat.h[j+1].At(lerp(lat.th[j+1][i], lat.th[j+1][i+1], s))
+	left := lat.v[i].At(lerp(lat.tv[j][i], lat.tv[j+1][i], t))
+	right := lat.v[i+1].At(lerp(lat.tv[j][i+1], lat.tv[j+1][i+1], t))
+	p00, p10, p01, p11 := lat.p[j][i], lat.p[j][i+1], lat.p[j+1][i], lat.p[j+1][i+1]
+	coons := func(top, bottom, left, right, p00, p10, p01, p11 float64) float64 {
+		return (1 - t) * top + t * bottom + (1 - s) * left + s * right -
+			((1 - s) * (1 - t) * p00 + s * (1 - t) * p10 + (1 - s) * t * p01 + s * t * p11)
+	}
+	return geometry.Float64Point{
+		X: coons(top.X, bottom.X, left.X, right.X, p00.X, p10.X, p01.X, p11.X),
+		Y: coons(top.Y, bottom.Y, left.Y, right.Y, p00.Y, p10.Y, p01.Y, p11.Y),
+	}
+}
+
+// the corners of the board in the order top-left, top-right, bottom-right,
+// bottom-left, as EdgeDetector.Corners
+func (g CurvedGrid) Corners() (c [4]geometry.Float64Point) {
+	lat := g.lattice()
+	if len(lat.v) < 2 || len(lat.h) < 2 {
+		return c
+	}
+	return [4]geometry.Float64Point{lat.At(0, 0), lat.At(1, 0), lat.At(1, 1), lat.At(0, 1)}
+}
+
+// the cell at row r, column c. its sides are curves, so each is sampled at
+// a few points and the result has more than four vertices.
+func (g CurvedGrid) CellQuad(r, c int) geometry.Polygon {
+	const per_side = 4
+	lat := g.lattice()
+	n := float64(SudokuGridDimension)
+	u0, u1 := float64(c) / n, float64(c+1) / n
+	v0, v1 := float64(r) / n, float64(r+1) / n
+	var poly geometry.Polygon
+	for k := 0; k < per_side; k++ {
+		poly = append(poly, lat.At(lerp(u0, u1, float64(k) / per_side), v0))
+	}
+	for k := 0; k < per_side; k++ {
+		poly = append(poly, lat.At(u1, lerp(v0, v1, float64(k) / per_side)))
+	}
+	for k := 0; k < per_side; k++ {
+		poly = append(poly, lat.At(lerp(u1, u0, float64(k) / per_side), v1))
+	}
+	for k := 0; k < per_side; k++ {
+		poly = append(poly, lat.At(u0, lerp(v1, v0, float64(k) / per_side)))
+	}
+	return poly
+}
+
+// the board resampled so every cell is a cell x cell square, in gray
+func (g CurvedGrid) Rectify(img image.Image, cell int) *image.Gray {
+	side := cell * SudokuGridDimension
+	out := image.NewGray(image.Rect(0, 0, side, side))
+	lat := g.lattice()
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			p := lat.At((float64(x) + 0.5) / float64(side), (float64(y) + 0.5) / float64(side))
+			lum := imaging.LuminanceAt(img, p.X, p.Y)
+			out.SetGray(x, y, color.Gray{uint8(math.Round(255.0 * lum))})
+		}
+	}
+	return out
+}
+
+func (g CurvedGrid) Draw(img image.Image) image.Image {
+	output := imaging.CopyImage(img)
+	for _, c := range g.curves {
+		drawing.Curve(output, c, 1.5, lineColor)
+	}
+	for _, c := range g.Corners() {
+		drawing.Circle(output, c, 3.0, cornerColor)
+	}
+	return output
+}
+
+// the curves as vectors over img, labeled like EdgeDetector.SVG with their
+// bend added
+func (g CurvedGrid) SVG(img image.Image, href string) *drawing.SVG {
+	s := drawing.NewSVG(img.Bounds())
+	if href == "" {
+		s.Image = img
+	} else {
+		s.ImageHref = href
+	}
+	for i, pot := range g.CurvePotentials(img) {
+		s.AddCurve(g.curves[i], 1.0, lineColor, fmt.Sprintf("%d: %.1f bend %.1f", i, pot, g.curves[i].Bend()))
+	}
+	for _, c := range g.Corners() {
+		s.AddPoint(c, 3.0, cornerColor, "")
+	}
+	return s
+}
@@ -0,0 +1,122 @@
+package alignment
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+
+	"github.com/twolfe18/sudoku/drawing"
+	"github.com/twolfe18/sudoku/geometry"
+	"github.com/twolfe18/sudoku/imaging"
+)
+
+// a straight lattice and a copy of it with every line bowed by bend, drawn
+// in black on white
+func bowedBoard(size int, bend float64) (EdgeDetector, CurvedGrid, image.Image) {
+	p := DefaultEdgeDetectorParams()
+	p.Padding = 10.0
+	p.Crappyness = 0.0
+	ed := NewEdgeDetector(geometry.NewFloat64Rectangle(image.Rect(0, 0, size, size)), p)
+	truth := NewCurvedGrid(ed)
+	for i := range truth.curves {
+		truth.curves[i].SetBend(bend)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	for _, c := range truth.curves {
+		drawing.Curve(img, c, 2.0, color.Black)
+	}
+	return ed, truth, img
+}
+
+func TestStraightCurvedGridMatchesEdgeDetector(t *testing.T) {
+	p := DefaultEdgeDetectorParams()
+	at := []float64{}
+	for i := 0; i <= SudokuGridDimension; i++ {
+		at = append(at, 5.0 + 10.0 * float64(i))
+	}
+	ed := lattice(p, 100.0, at...)
+	g := NewCurvedGrid(ed)
+	for i, c := range g.Corners() {
+		if !c.Equals(ed.Corners()[i]) {
+			t.Errorf("corner %d at %s, EdgeDetector has %s", i, c, ed.Corners()[i])
+		}
+	}
+	for r := 0; r < SudokuGridDimension; r++ {
+		for c := 0; c < SudokuGridDimension; c++ {
+			if iou := geometry.IoU(g.CellQuad(r, c), ed.CellQuad(r, c)); iou < 0.999 {
+				t.Errorf("cell (%d, %d) has IoU %.4f with the straight lattice", r, c, iou)
+			}
+		}
+	}
+}
+
+// evaluate and tune compare the two models, so they have to weigh ink alike
+func TestStraightCurvedGridHasSameInk(t *testing.T) {
+	ed, _, img := bowedBoard(80, 2.0)
+	lines := ed.LinePotentials(img)
+	for i, pot := range NewCurvedGrid(ed).CurvePotentials(img) {
+		if math.Abs(pot - lines[i]) > 1e-9 * math.Max(1.0, lines[i]) {
+			t.Errorf("curve %d has ink %g, its line has %g", i, pot, lines[i])
+		}
+	}
+}
+
+func TestCurvedGridFindsBow(t *testing.T) {
+	ed, truth, img := bowedBoard(80, 4.0)
+	g := NewCurvedGrid(ed)
+	g.params.BendIterations = 10
+	g.params.NumProposals = 10
+	g.params.Greedyness = 10.0	// near greedy, so the answer doesn't wander
+	g, err := g.AlignTo(img, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range g.Curves() {
+		if want := truth.curves[i].Bend(); math.Abs(c.Bend() - want) > 1.5 {
+			t.Errorf("curve %d bent %.2f, want %.2f", i, c.Bend(), want)
+		}
+	}
+	if g.Potential(img) <= NewCurvedGrid(ed).Potential(img) {
+		t.Errorf("bending should beat the straight lattice on a bowed board")
+	}
+}
+
+func TestRectifyStraightensBow(t *testing.T) {
+	_, truth, img := bowedBoard(80, 4.0)
+	const cell = 8
+	out := truth.Rectify(img, cell)
+	side := cell * SudokuGridDimension
+	if out.Bounds() != image.Rect(0, 0, side, side) {
+		t.Fatalf("rectified to %v", out.Bounds())
+	}
+
+	// 4 lines a side, so the inner lines land a third of the way in. the
+	// columns along one should be dark all the way down, one between them
+	// only crosses the horizontal lines.
+	column := func(x int) (mean float64) {
+		for y := 0; y < side; y++ {
+			mean += imaging.DarknessAt(out, x, y) / float64(side)
+		}
+		return mean
+	}
+	if d := column(side / 3 - 1) + column(side / 3); d < 0.8 {
+		t.Errorf("line column is broken, darkness only %.2f", d)
+	}
+	if d := column(side / 6); d > 0.3 {
+		t.Errorf("column between lines has too much ink, darkness %.2f", d)
+	}
+}
+
+func TestCurvedGridDraw(t *testing.T) {
+	ed, _, img := bowedBoard(48, 3.0)
+	g := NewCurvedGrid(ed)
+	if out := g.Draw(img); out.Bounds() != img.Bounds() {
+		t.Errorf("drew onto %v", out.Bounds())
+	}
+	if s := g.SVG(img, "board.png"); len(s.Curves) != len(ed.lines) {
+		t.Errorf("%d curves in the svg, want %d", len(s.Curves), len(ed.lines))
+	}
+}
@@ -0,0 +1,158 @@
+package alignment
+
+import "fmt"
+
+// EdgeDetectorParams holds every tuning knob for the grid aligner
+type EdgeDetectorParams struct {
+	// potential += exp(-sq_dist(point,pixel) / radius)
+	LineRadius float64 `json:"line_radius"`
+
+	// the geometric prior, see prior.go. each penalty is about 1 at its worst
+	// and is subtracted from the potential times its weight.
+	// lines in a family should be parallel
+	ParallelWeight float64 `json:"parallel_weight"`
+	// the two families should be at right angles
+	OrthogonalWeight float64 `json:"orthogonal_weight"`
+	// lines in a family should be evenly spaced
+	SpacingWeight float64 `json:"spacing_weight"`
+
+	// potential += cross_weight * mean cross shaped darkness at the
+	// lattice intersections, 0 turns it off
+	CrossWeight float64 `json:"cross_weight"`
+
+	// length of each arm of the cross template (pixels)
+	CrossArm float64 `json:"cross_arm"`
+
+	// how many proposals to make at each hill climbing iteration
+	NumProposals uint `json:"num_proposals"`
+
+	// proposals are chosen with prob: l1_normalize(potentials ^ greedyness).
+	// 0 is uniform choice, infinity is perfectly greedy
+	Greedyness float64 `json:"greedyness"`
+
+	// size of random moves, annealed by AlignTo and multiplied by variance
+	// for each kind of move
+	ProposalVariance float64 `json:"proposal_variance"`
+
+	// rotate (degrees), shift_x, shift_y (pixels), scale_x, scale_y
+	// (fraction) and normal (pixels, per_line only) per unit of
+	// proposal_variance
+	Variance Variance `json:"variance"`
+
+	// how much of the proposal is shared across lines vs drawn for each line
+	IndependentScale float64 `json:"independent_scale"`
+
+	// how the shared part of each proposal is drawn: joint, coordinate,
+	// laplace or spike_slab, or per_line to move lines one by one (see
+	// proposal.go)
+	ProposalMode string `json:"proposal_mode"`
+
+	// chance that each part of a spike_slab step is exactly zero
+	SpikeProb float64 `json:"spike_prob"`
+
+	// proposals are ranked by potential + prior_weight * log prior(step)
+	PriorWeight float64 `json:"prior_weight"`
+
+	// distance from the image border to the initial grid
+	Padding float64 `json:"padding"`
+
+	// lines in each direction of the initial grid
+	NumLines int `json:"num_lines"`
+
+	// how far the initial grid is perturbed, as a multiple of ProposalVariance
+	Crappyness float64 `json:"crappyness"`
+
+	// hill climbing iterations in AlignTo
+	NumIterations int `json:"num_iterations"`
+
+	// for warped pages, see curved.go. iterations of CurvedGrid.AlignTo
+	// after the straight fit, 0 leaves every line straight
+	BendIterations int `json:"bend_iterations"`
+
+	// how far the middle of each line moves off its chord per proposal
+	// (pixels, times proposal_variance)
+	BendVariance float64 `json:"bend_variance"`
+
+	// potential -= bend_weight * mean squared bend (pixels)
+	BendWeight float64 `json:"bend_weight"`
+
+	// every random draw of NewEdgeDetector, AlignTo and the CurvedGrid
+	// comes from this, so a run can be repeated exactly
+	Seed int64 `json:"seed"`
+}
+
+func DefaultEdgeDetectorParams() (p EdgeDetectorParams) {
+	p.LineRadius = 1.0
+	p.ParallelWeight = 10.0
+	p.OrthogonalWeight = 10.0
+	p.SpacingWeight = 10.0
+	p.CrossWeight = 0.0
+	p.CrossArm = 4.0
+	p.NumProposals = 75
+	p.Greedyness = 2.5
+	p.ProposalVariance = 4.0	// in degrees
+	p.Variance = DefaultVariance()
+	p.IndependentScale = 0.1
+	p.ProposalMode = ProposalJoint
+	p.SpikeProb = 0.5
+	p.PriorWeight = 1.0
+	p.Padding = 60.0	//2.0
+	p.NumLines = 4	//SudokuGridDimension + 1
+	p.Crappyness = 6.0
+	p.NumIterations = 15
+	p.BendIterations = 0
+	p.Seed = 1
+	p.BendVariance = 0.5
+	p.BendWeight = 0.1
+	return p
+}
+
+func (p EdgeDetectorParams) Validate() error {
+	switch {
+	case p.LineRadius <= 0.0:
+		return fmt.Errorf("edge_detector.line_radius must be > 0, got %g", p.LineRadius)
+	case p.ParallelWeight < 0.0:
+		return fmt.Errorf("edge_detector.parallel_weight must be >= 0, got %g", p.ParallelWeight)
+	case p.OrthogonalWeight < 0.0:
+		return fmt.Errorf("edge_detector.orthogonal_weight must be >= 0, got %g", p.OrthogonalWeight)
+	case p.SpacingWeight < 0.0:
+		return fmt.Errorf("edge_detector.spacing_weight must be >= 0, got %g", p.SpacingWeight)
+	case p.CrossWeight < 0.0:
+		return fmt.Errorf("edge_detector.cross_weight must be >= 0, got %g", p.CrossWeight)
+	case p.CrossArm < 2.0:
+		return fmt.Errorf("edge_detector.cross_arm must be >= 2, got %g", p.CrossArm)
+	case p.NumProposals == 0:
+		return fmt.Errorf("edge_detector.num_proposals must be > 0")
+	case p.Greedyness < 0.0:
+		return fmt.Errorf("edge_detector.greedyness must be >= 0, got %g", p.Greedyness)
+	case p.ProposalVariance <= 0.0:
+		return fmt.Errorf("edge_detector.proposal_variance must be > 0, got %g", p.ProposalVariance)
+	case p.Variance.validate() != nil:
+		return p.Variance.validate()
+	case p.IndependentScale < 0.0:
+		return fmt.Errorf("edge_detector.independent_scale must be >= 0, got %g", p.IndependentScale)
+	case validProposalMode(p.ProposalMode) != nil:
+		return validProposalMode(p.ProposalMode)
+	case p.ProposalMode == ProposalPerLine && p.Variance.Normal == 0.0:
+		return fmt.Errorf("edge_detector.variance.normal must be > 0 for per_line proposals")
+	case p.SpikeProb < 0.0 || p.SpikeProb >= 1.0:
+		return fmt.Errorf("edge_detector.spike_prob must be in [0,1), got %g", p.SpikeProb)
+	case p.PriorWeight < 0.0:
+		return fmt.Errorf("edge_detector.prior_weight must be >= 0, got %g", p.PriorWeight)
+	case p.Padding < 0.0:
+		return fmt.Errorf("edge_detector.padding must be >= 0, got %g", p.Padding)
+	case p.NumLines < 2:
+		return fmt.Errorf("edge_detector.num_lines must be >= 2, got %d", p.NumLines)
+	case p.Crappyness < 0.0:
+		return fmt.Errorf("edge_detector.crappyness must be >= 0, got %g", p.Crappyness)
+	case p.NumIterations < 0:
+		return fmt.Errorf("edge_detector.num_iterations must be >= 0, got %d", p.NumIterations)
+	case p.BendIterations < 0:
+		return fmt.Errorf("edge_detector.bend_iterations must be >= 0, got %d", p.BendIterations)
+	case p.BendVariance <= 0.0:
+		return fmt.Errorf("edge_detector.bend_variance must be > 0, got %g", p.BendVariance)
+	case p.BendWeight < 0.0:
+		return fmt.Errorf("edge_detector.bend_weight must be >= 0, got %g", p.BendWeight)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package alignment
+
+import (
+	"image"
+	"math"
+
+	"github.com/twolfe18/sudoku/geometry"
+)
+
+// each part of EdgeDetector.Potential, already multiplied by its weight.
+// Total = Data + Cross - Parallel - Orthogonal - Spacing - Bend.
+type PotentialTerms struct {
+	Data float64 `json:"data"`	// mean ink under each line
+	Cross float64 `json:"cross"`	// crosses at the intersections
+	Parallel float64 `json:"parallel"`	// penalty, lines in a family not parallel
+	Orthogonal float64 `json:"orthogonal"`	// penalty, families not at right angles
+	Spacing float64 `json:"spacing"`	// penalty, uneven gaps within a family
+	Bend float64 `json:"bend,omitempty"`	// penalty, CurvedGrid only
+	Total float64 `json:"total"`
+}
+
+func sinSq(degrees float64) float64 {
+	s := math.Sin(degrees * math.Pi / 180.0)
+	return s * s
+}
+
+// mean sin^2 of the angle between every pair of lines in fam, 0 when
+// they are all parallel and 1 at worst
+func parallelPenalty(fam []geometry.Line) float64 {
+	total, n := 0.0, 0
+	for i := 1; i < len(fam); i++ {
+		for j := 0; j < i; j++ {
+			total += sinSq(fam[i].Angle(fam[j]))
+			n++
+		}
+	}
+	if n == 0 {
+		return 0.0
+	}
+	return total / float64(n)
+}
+
+// mean cos^2 of the angle between every vertical and every horizontal
+// line, 0 when the families are perpendicular
+func orthogonalPenalty(v, h []geometry.Line) float64 {
+	total, n := 0.0, 0
+	for _, a := range v {
+		for _, b := range h {
+			total += 1.0 - sinSq(a.Angle(b))
+			n++
+		}
+	}
+	if n == 0 {
+		return 0.0
+	}
+	return total / float64(n)
+}
+
+// squared coefficient of variation of the gaps between neighbouring lines
+// in fam (sorted, as Family returns it). 0 for even spacing, scale free so
+// a small board is held to the same standard as a big one.
+func spacingPenalty(fam []geometry.Line) float64 {
+	if len(fam) < 3 {
+		return 0.0
+	}
+	gaps := make([]float64, len(fam) - 1)
+	mean := 0.0
+	for i := range gaps {
+		m := fam[i+1].Midpoint()
+		gaps[i] = fam[i].Distance(m.X, m.Y)
+		mean += gaps[i]
+	}
+	mean /= float64(len(gaps))
+	if mean == 0.0 {
+		return 1.0	// everything on top of each other is as bad as it gets
+	}
+	v := 0.0
+	for _, g := range gaps {
+		v += (g - mean) * (g - mean)
+	}
+	v /= float64(len(gaps))
+	return v / (mean * mean)
+}
+
+// the geometric prior on its own, without looking at the image
+func (ed EdgeDetector) priorTerms() (t PotentialTerms) {
+	v := ed.Family(true)
+	h := ed.Family(false)
+	t.Parallel = ed.params.ParallelWeight * (parallelPenalty(v) + parallelPenalty(h)) / 2.0
+	t.Orthogonal = ed.params.OrthogonalWeight * orthogonalPenalty(v, h)
+	t.Spacing = ed.params.SpacingWeight * (spacingPenalty(v) + spacingPenalty(h)) / 2.0
+	return t
+}
+
+func (ed EdgeDetector) PotentialTerms(img image.Image) (t PotentialTerms) {
+	t = ed.priorTerms()
+
+	// activation for each line and pixel
+	for _, lp := range ed.LinePotentials(img) {
+		t.Data += lp
+	}
+	t.Data /= float64(len(ed.lines))
+
+	// extra benefit for getting a cross at two intersecting lines. this
+	// could get fooled on the numbers, so it's off unless cross_weight > 0,
+	// and evaluate -ablate cross says whether it pays for itself.
+	if ed.params.CrossWeight > 0.0 {
+		t.Cross = ed.params.CrossWeight * ed.CrossScore(img)
+	}
+
+	t.Total = t.Data + t.Cross - t.Parallel - t.Orthogonal - t.Spacing
+	return t
+}
@@ -0,0 +1,72 @@
+package alignment
+
+import (
+	"math"
+	"testing"
+
+	"github.com/twolfe18/sudoku/geometry"
+)
+
+func TestPerfectLatticeHasNoPenalty(t *testing.T) {
+	ed := lattice(DefaultEdgeDetectorParams(), 100, 10, 30, 50, 70, 90)
+	terms := ed.priorTerms()
+	if terms.Parallel != 0.0 || terms.Orthogonal > 1e-12 || terms.Spacing > 1e-12 {
+		t.Errorf("a square lattice was penalized: %+v", terms)
+	}
+	img := gridImage(32)
+	if pt := ed.PotentialTerms(img); math.Abs(pt.Total - pt.Data) > 1e-9 {
+		t.Errorf("total %.3f should just be the data term %.3f", pt.Total, pt.Data)
+	}
+}
+
+func TestPriorTerms(t *testing.T) {
+	p := DefaultEdgeDetectorParams()
+	// tilt one vertical line: not parallel to its family, and not quite
+	// perpendicular to the horizontals
+	tilted := lattice(p, 100, 10, 30, 50, 70, 90)
+	tilted.lines[4].Rotate(10.0 * math.Pi / 180.0)
+	a := tilted.priorTerms()
+	if a.Parallel <= 0.0 || a.Orthogonal <= 0.0 {
+		t.Errorf("tilting a line should cost something: %+v", a)
+	}
+
+	// shear a whole family: still parallel, no longer orthogonal
+	sheared := lattice(p, 100, 10, 30, 50, 70, 90)
+	for i, l := range sheared.lines {
+		if sheared.IsVertical(i) {
+			l.Right.X += 20.0
+			sheared.lines[i] = l
+		}
+	}
+	b := sheared.priorTerms()
+	if b.Parallel > 1e-12 || b.Orthogonal <= a.Orthogonal {
+		t.Errorf("shearing should only cost orthogonality: %+v", b)
+	}
+
+	// move one horizontal line off its spot
+	uneven := lattice(p, 100, 10, 30, 50, 70, 90)
+	uneven.lines[5].Shift(0.0, 12.0)
+	if c := uneven.priorTerms(); c.Spacing <= 0.0 || c.Parallel != 0.0 {
+		t.Errorf("uneven spacing should only cost spacing: %+v", c)
+	}
+
+	// the weights scale each term on its own
+	p.ParallelWeight, p.OrthogonalWeight, p.SpacingWeight = 0.0, 0.0, 0.0
+	tilted.params = p
+	if z := tilted.priorTerms(); z != (PotentialTerms{}) {
+		t.Errorf("zero weights should turn the prior off: %+v", z)
+	}
+}
+
+func TestParallelPenaltyPrefersParallel(t *testing.T) {
+	// the old orientation term penalized parallel lines the most
+	a := geometry.Line{Left: geometry.Float64Point{X: 0, Y: 0}, Right: geometry.Float64Point{X: 10, Y: 0}}
+	b := geometry.Line{Left: geometry.Float64Point{X: 0, Y: 5}, Right: geometry.Float64Point{X: 10, Y: 5}}
+	c := geometry.Line{Left: geometry.Float64Point{X: 0, Y: 5}, Right: geometry.Float64Point{X: 10, Y: 8}}
+	if parallelPenalty([]geometry.Line{a, b}) >= parallelPenalty([]geometry.Line{a, c}) {
+		t.Errorf("parallel lines should be preferred")
+	}
+	if spacingPenalty([]geometry.Line{a, b}) != 0.0 {
+		t.Errorf("two lines have only one gap, nothing to compare")
+	}
+}
@@ -0,0 +1,213 @@
+package alignment
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// how Proposal draws the step shared by every line
+const (
+	// rotate and shift together, each uniform in +-variance
+	ProposalJoint = "joint"
+	// rotate or shift x or shift y, one at a time
+	ProposalCoordinate = "coordinate"
+	// each part laplace distributed, mostly small with the odd big move
+	ProposalLaplace = "laplace"
+	// each part is exactly zero with probability spike_prob, otherwise uniform
+	ProposalSpikeSlab = "spike_slab"
+	// nothing shared, each line slides along its own normal. orientations
+	// are kept but spacing can change, for boards that aren't evenly printed
+	ProposalPerLine = "per_line"
+)
+
+var ProposalModes = []string{ProposalJoint, ProposalCoordinate, ProposalLaplace, ProposalSpikeSlab, ProposalPerLine}
+
+func validProposalMode(m string) error {
+	for _, v := range ProposalModes {
+		if m == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("edge_detector.proposal_mode must be one of %v, got %q", ProposalModes, m)
+}
+
+// the part of a proposal shared by every line, before each line's own jitter
+type Step struct {
+	Theta float64 `json:"theta"`	// degrees
+	DX float64 `json:"dx"`	// pixels
+	DY float64 `json:"dy"`
+	ScaleX float64 `json:"scale_x"`	// stretch about the lattice centroid, 0.05 is 5% wider
+	ScaleY float64 `json:"scale_y"`
+	Normal []float64 `json:"normal,omitempty"`	// per_line only, pixels along each line's normal
+}
+
+func (s Step) parts() []float64 { return []float64{s.Theta, s.DX, s.DY, s.ScaleX, s.ScaleY} }
+
+func (s Step) nonzero() (n int) {
+	for _, x := range s.parts() {
+		if x != 0.0 { n++ }
+	}
+	return n
+}
+
+// how big each kind of move is, in units of proposal_variance. the
+// defaults give rotations in degrees and shifts in pixels of the same
+// size, and stretches of a percent per unit.
+type Variance struct {
+	Rotate float64 `json:"rotate"`
+	ShiftX float64 `json:"shift_x"`
+	ShiftY float64 `json:"shift_y"`
+	ScaleX float64 `json:"scale_x"`
+	ScaleY float64 `json:"scale_y"`
+	Normal float64 `json:"normal"`	// per_line only
+}
+
+func DefaultVariance() Variance {
+	return Variance{Rotate: 1.0, ShiftX: 1.0, ShiftY: 1.0, ScaleX: 0.01, ScaleY: 0.01, Normal: 1.0}
+}
+
+func (v Variance) validate() error {
+	if v.Rotate < 0.0 || v.ShiftX < 0.0 || v.ShiftY < 0.0 || v.ScaleX < 0.0 || v.ScaleY < 0.0 || v.Normal < 0.0 {
+		return fmt.Errorf("edge_detector.variance must not be negative, got %+v", v)
+	}
+	if v.Rotate + v.ShiftX + v.ShiftY + v.ScaleX + v.ScaleY + v.Normal == 0.0 {
+		return fmt.Errorf("edge_detector.variance is all zero, nothing would ever move")
+	}
+	return nil
+}
+
+// each part of a step with how far it may go. parts with no room to
+// move are left out, they are always zero.
+type stepPart struct {
+	x *float64
+	max float64
+}
+
+func (p EdgeDetectorParams) stepParts(s *Step, variance float64) (parts []stepPart) {
+	v := p.Variance
+	for _, sp := range []stepPart{
+		{&s.Theta, v.Rotate}, {&s.DX, v.ShiftX}, {&s.DY, v.ShiftY}, {&s.ScaleX, v.ScaleX}, {&s.ScaleY, v.ScaleY},
+	} {
+		sp.max *= variance
+		if sp.max > 0.0 {
+			parts = append(parts, sp)
+		}
+	}
+	return parts
+}
+
+func uniform(rng *rand.Rand, v float64) float64 {
+	return (rng.Float64() * 2.0 - 1.0) * v
+}
+
+// laplace with scale b by inverting the cdf
+func laplace(rng *rand.Rand, b float64) float64 {
+	u := rng.Float64() - 0.5
+	if u < 0.0 {
+		return b * math.Log(1.0 + 2.0 * u)
+	}
+	return -b * math.Log(1.0 - 2.0 * u)
+}
+
+// draws a step, each part on the scale of variance times its Variance
+func (p EdgeDetectorParams) drawStep(rng *rand.Rand, variance float64) (s Step) {
+	parts := p.stepParts(&s, variance)
+	if len(parts) == 0 {
+		return s
+	}
+	switch p.ProposalMode {
+	case ProposalCoordinate:
+		sp := parts[rng.Intn(len(parts))]
+		*sp.x = uniform(rng, sp.max)
+	case ProposalLaplace:
+		for _, sp := range parts {
+			*sp.x = laplace(rng, sp.max / 2.0)	// so the mean move matches joint's
+		}
+	case ProposalSpikeSlab:
+		for _, sp := range parts {
+			if rng.Float64() >= p.SpikeProb {
+				*sp.x = uniform(rng, sp.max)
+			}
+		}
+	default:
+		for _, sp := range parts {
+			*sp.x = uniform(rng, sp.max)
+		}
+	}
+	return s
+}
+
+// log density of s under the prior it was drawn from. AlignTo adds
+// prior_weight times this to each proposal's Potential, so the sparse
+// priors prefer moves that change little. joint and coordinate are flat
+// within their range and so only rule out impossible steps.
+func (p EdgeDetectorParams) LogPrior(s Step, variance float64) (lp float64) {
+	if p.ProposalMode == ProposalPerLine {
+		return p.perLinePrior(s, variance)
+	}
+	if len(s.Normal) > 0 {
+		return math.Inf(-1)
+	}
+	parts := p.stepParts(&s, variance)
+	moving := 0
+	for _, sp := range parts {
+		if *sp.x != 0.0 { moving++ }
+	}
+	// parts with no room to move must not have
+	if moving != s.nonzero() {
+		return math.Inf(-1)
+	}
+
+	outside := func(sp stepPart) bool { return math.Abs(*sp.x) > sp.max }
+	switch p.ProposalMode {
+	case ProposalCoordinate:
+		if moving > 1 {
+			return math.Inf(-1)
+		}
+		lp = -math.Log(float64(len(parts)))	// which part moves
+		for _, sp := range parts {
+			if outside(sp) { return math.Inf(-1) }
+			if *sp.x != 0.0 { lp -= math.Log(2.0 * sp.max) }
+		}
+		return lp
+	case ProposalLaplace:
+		for _, sp := range parts {
+			b := sp.max / 2.0
+			lp += -math.Log(2.0 * b) - math.Abs(*sp.x) / b
+		}
+		return lp
+	case ProposalSpikeSlab:
+		// the spike is a point mass, count it as a probability
+		for _, sp := range parts {
+			switch {
+			case *sp.x == 0.0:
+				lp += math.Log(p.SpikeProb)
+			case outside(sp):
+				return math.Inf(-1)
+			default:
+				lp += math.Log(1.0 - p.SpikeProb) - math.Log(2.0 * sp.max)
+			}
+		}
+		return lp
+	}
+	for _, sp := range parts {
+		if outside(sp) { return math.Inf(-1) }
+		lp -= math.Log(2.0 * sp.max)
+	}
+	return lp
+}
+
+// a per_line step moves every line by its own uniform amount along its
+// normal and shares nothing
+func (p EdgeDetectorParams) perLinePrior(s Step, variance float64) (lp float64) {
+	if s.nonzero() > 0 {
+		return math.Inf(-1)
+	}
+	max := p.Variance.Normal * variance
+	for _, d := range s.Normal {
+		if math.Abs(d) > max { return math.Inf(-1) }
+		if max > 0.0 { lp -= math.Log(2.0 * max) }
+	}
+	return lp
+}
@@ -0,0 +1,208 @@
+package alignment
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/twolfe18/sudoku/debugsink"
+	"github.com/twolfe18/sudoku/geometry"
+)
+
+func modeParams(mode string) EdgeDetectorParams {
+	p := DefaultEdgeDetectorParams()
+	p.ProposalMode = mode
+	return p
+}
+
+func TestCoordinateStepsMoveOneThing(t *testing.T) {
+	p := modeParams(ProposalCoordinate)
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		s := p.drawStep(rng, 4.0)
+		nonzero := 0
+		for _, x := range s.parts() {
+			if x != 0.0 { nonzero++ }
+		}
+		if math.Abs(s.Theta) > 4.0 || math.Abs(s.DX) > 4.0 || math.Abs(s.ScaleY) > 0.04 {
+			t.Fatalf("%+v is out of range", s)
+		}
+		if nonzero != 1 {
+			t.Fatalf("coordinate step %+v moves %d things", s, nonzero)
+		}
+		if math.IsInf(p.LogPrior(s, 4.0), -1) {
+			t.Fatalf("%+v drawn but has zero prior", s)
+		}
+	}
+	if !math.IsInf(p.LogPrior(Step{Theta: 1.0, DX: 1.0}, 4.0), -1) {
+		t.Errorf("coordinate prior should rule out moving two things")
+	}
+}
+
+func TestSpikeSlabZeros(t *testing.T) {
+	p := modeParams(ProposalSpikeSlab)
+	rng := rand.New(rand.NewSource(1))
+	p.SpikeProb = 0.7
+	zeros, n := 0, 3000
+	for i := 0; i < n; i++ {
+		for _, x := range p.drawStep(rng, 4.0).parts() {
+			if x == 0.0 { zeros++ }
+		}
+	}
+	if frac := float64(zeros) / float64(5 * n); math.Abs(frac - 0.7) > 0.03 {
+		t.Errorf("%.3f of parts were zero, expected 0.7", frac)
+	}
+	if p.LogPrior(Step{}, 4.0) <= p.LogPrior(Step{Theta: 0.5}, 4.0) {
+		t.Errorf("the spike should be more likely than a move")
+	}
+}
+
+func TestLaplacePrior(t *testing.T) {
+	p := modeParams(ProposalLaplace)
+	rng := rand.New(rand.NewSource(1))
+	sum, n := 0.0, 20000
+	for i := 0; i < n; i++ {
+		sum += math.Abs(p.drawStep(rng, 4.0).DX)
+	}
+	// mean |x| of a laplace is its scale, variance / 2
+	if mean := sum / float64(n); math.Abs(mean - 2.0) > 0.1 {
+		t.Errorf("mean |dx| = %.3f, expected 2", mean)
+	}
+	// density is exp(-|x|/b) / 2b in each part, b is 2 for the shifts
+	// and rotation and 0.02 for the stretches
+	want := 3.0 * -math.Log(4.0) - 3.0 / 2.0 + 2.0 * -math.Log(0.04) - 2.0 * 0.01 / 0.02
+	if got := p.LogPrior(Step{Theta: 1.0, DX: -1.0, DY: 1.0, ScaleX: 0.01, ScaleY: -0.01}, 4.0); math.Abs(got - want) > 1e-12 {
+		t.Errorf("log prior %.4f, want %.4f", got, want)
+	}
+	if p.LogPrior(Step{Theta: 0.1}, 4.0) <= p.LogPrior(Step{Theta: 2, DX: 2}, 4.0) {
+		t.Errorf("small steps should be preferred")
+	}
+}
+
+func TestJointPriorIsFlat(t *testing.T) {
+	p := modeParams(ProposalJoint)
+	rng := rand.New(rand.NewSource(1))
+	if p.LogPrior(Step{}, 4.0) != p.LogPrior(Step{Theta: 3.9, DX: -3.9, DY: 1, ScaleX: 0.02}, 4.0) {
+		t.Errorf("joint prior should not prefer any step in range")
+	}
+	if !math.IsInf(p.LogPrior(Step{Theta: 4.5}, 4.0), -1) || !math.IsInf(p.LogPrior(Step{ScaleX: 0.05}, 4.0), -1) {
+		t.Errorf("joint prior should rule out steps out of range")
+	}
+
+	// turning a kind of move off makes it impossible
+	p.Variance.ScaleX = 0.0
+	if s := p.drawStep(rng, 4.0); s.ScaleX != 0.0 {
+		t.Errorf("scale_x is off but moved: %+v", s)
+	}
+	if !math.IsInf(p.LogPrior(Step{ScaleX: 0.01}, 4.0), -1) {
+		t.Errorf("a step that can't happen should have zero prior")
+	}
+}
+
+func TestProposalModeValidation(t *testing.T) {
+	for _, m := range ProposalModes {
+		if err := modeParams(m).Validate(); err != nil {
+			t.Errorf("%s: %s", m, err)
+		}
+	}
+	if modeParams("gradient").Validate() == nil {
+		t.Errorf("unknown proposal mode should not validate")
+	}
+}
+
+func TestAlignToEveryMode(t *testing.T) {
+	img := gridImage(32)
+	for _, m := range ProposalModes {
+		p := smallParams()
+		p.ProposalMode = m
+		mem := debugsink.NewMemory()
+		ed := NewEdgeDetector(geometry.NewFloat64Rectangle(img.Bounds()), p)
+		if _, err := ed.AlignTo(img, mem); err != nil {
+			t.Fatalf("%s: %s", m, err)
+		}
+		step := mem.Values["align.000.proposals"].(AlignStep)
+		for _, lp := range step.LogPriors {
+			if math.IsInf(lp, 0) || math.IsNaN(lp) {
+				t.Errorf("%s: proposal with log prior %v", m, lp)
+			}
+		}
+	}
+}
+
+func TestStretchAboutCentroid(t *testing.T) {
+	p := DefaultEdgeDetectorParams()
+	p.Padding = 30.0
+	p.Crappyness = 0.0
+	p.IndependentScale = 0.0
+	p.Variance = Variance{ScaleX: 0.05}
+	b := geometry.NewFloat64Rectangle(gridImage(100).Bounds())
+	ed := NewEdgeDetector(b, p)
+	for i := 0; i < 20; i++ {
+		n, s := ed.Proposal(b)
+		if s.ScaleX == 0.0 { continue }
+		old_v, new_v := ed.Family(true), n.Family(true)
+		old_h, new_h := ed.Family(false), n.Family(false)
+		for j := range old_v {
+			// x moves away from the center at 50, y stays put
+			want := 50.0 + (old_v[j].Left.X - 50.0) * (1.0 + s.ScaleX)
+			if math.Abs(new_v[j].Left.X - want) > 1e-9 || math.Abs(new_v[j].Left.Y - old_v[j].Left.Y) > 1e-9 {
+				t.Fatalf("step %+v: vertical %s should be at x=%.3f", s, new_v[j], want)
+			}
+			if math.Abs(new_h[j].Left.Y - old_h[j].Left.Y) > 1e-9 {
+				t.Fatalf("step %+v: horizontal %s moved up or down", s, new_h[j])
+			}
+		}
+	}
+}
+
+func TestVarianceValidation(t *testing.T) {
+	p := DefaultEdgeDetectorParams()
+	p.Variance.ScaleY = -0.01
+	if p.Validate() == nil {
+		t.Errorf("negative variance should not validate")
+	}
+	p.Variance = Variance{}
+	if p.Validate() == nil {
+		t.Errorf("all zero variance should not validate")
+	}
+}
+
+func TestPerLineKeepsOrientation(t *testing.T) {
+	p := modeParams(ProposalPerLine)
+	p.Padding = 30.0
+	b := geometry.NewFloat64Rectangle(gridImage(100).Bounds())
+	ed := NewEdgeDetector(b, p)
+	n, s := ed.Proposal(b)
+	if len(s.Normal) != len(ed.lines) || s.nonzero() != 0 {
+		t.Fatalf("per_line step should only have a shift per line: %+v", s)
+	}
+	moved := 0
+	for i, l := range n.Lines() {
+		old := ed.lines[i]
+		if math.Abs(l.Angle(old)) > 1e-9 {
+			t.Errorf("line %d turned by %g degrees", i, l.Angle(old))
+		}
+		// the shift is all along the normal
+		m := l.Midpoint()
+		if d := old.Distance(m.X, m.Y); math.Abs(d - math.Abs(s.Normal[i])) > 1e-9 {
+			t.Errorf("line %d moved %g off itself, step says %g", i, d, s.Normal[i])
+		}
+		if s.Normal[i] != 0.0 { moved++ }
+	}
+	if moved < 2 {
+		t.Errorf("lines should move independently, only %d moved", moved)
+	}
+	if math.IsInf(p.LogPrior(s, n.proposal_variance), -1) {
+		t.Errorf("%+v drawn but has zero prior", s)
+	}
+	if !math.IsInf(p.LogPrior(Step{DX: 1.0, Normal: s.Normal}, 4.0), -1) {
+		t.Errorf("per_line prior should rule out shared moves")
+	}
+	if !math.IsInf(modeParams(ProposalJoint).LogPrior(Step{Normal: []float64{1.0}}, 4.0), -1) {
+		t.Errorf("joint prior should rule out per line moves")
+	}
+	p.Variance.Normal = 0.0
+	if p.Validate() == nil {
+		t.Errorf("per_line with no normal variance should not validate")
+	}
+}
@@ -0,0 +1,47 @@
+package alignment
+
+import (
+	"fmt"
+	"math/rand"
+	"math"
+)
+
+// picks index i with probability weights[i] / sum(weights)
+func WeightedChoice(rng *rand.Rand, weights []float64) (int, error) {
+	s := 0.0
+	for i,v := range weights {
+		if v < 0.0 || math.IsNaN(v) || math.IsInf(v, 0) {
+			return -1, fmt.Errorf("[WeightedChoice] illegal weight at %d: %.2f", i, v)
+		}
+		s += v
+	}
+	if s == 0.0 {
+		return -1, fmt.Errorf("[WeightedChoice] all %d weights are 0", len(weights))
+	}
+	cutoff := rng.Float64() * s
+	s = 0.0
+	for i,v := range weights {
+		s += v
+		if s > cutoff { return i, nil }
+	}
+	// rounding can leave the sum a hair short of cutoff
+	for i := len(weights) - 1; i >= 0; i-- {
+		if weights[i] > 0.0 { return i, nil }
+	}
+	return -1, fmt.Errorf("[WeightedChoice] s = %.2f, cutoff = %.2f, weights = %v", s, cutoff, weights)
+}
+
+// turns potentials (bigger is better) into weights for WeightedChoice: the
+// worst becomes 1 and everything is raised to greedyness. overwrites and
+// returns potentials.
+func greedyWeights(potentials []float64, greedyness float64) []float64 {
+	minp := math.Inf(1)
+	for _, p := range potentials {
+		if p < minp { minp = p }
+	}
+	for i,_ := range potentials {
+		c := potentials[i] - minp + 1	// smallest proposal will have potential = 1.0
+		potentials[i] = math.Pow(c, greedyness)
+	}
+	return potentials
+}
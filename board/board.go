@@ -0,0 +1,89 @@
+// Package board is the puzzle itself, independent of any image: a Board
+// of digits and the text formats boards are passed around in.
+package board
+
+import "fmt"
+
+const (
+	Size = 9	// side of the board in cells
+	BoxSize = 3	// side of a box in cells
+	NumCells = Size * Size
+)
+
+// the digits in row major order, 0 for blank. a Board is a value, copying
+// it copies the puzzle.
+type Board [NumCells]int
+
+// the board from 81 cells in row major order, as alignment.Annotation keeps them
+func FromCells(cells []int) (b Board, err error) {
+	if len(cells) != NumCells {
+		return b, fmt.Errorf("[FromCells] expected %d cells, got %d", NumCells, len(cells))
+	}
+	for i, v := range cells {
+		if v < 0 || v > Size {
+			return b, fmt.Errorf("[FromCells] row %d, column %d: %d is not in [0, %d]", i / Size + 1, i % Size + 1, v, Size)
+		}
+		b[i] = v
+	}
+	return b, nil
+}
+
+func (b Board) Cells() []int {
+	return append([]int(nil), b[:]...)
+}
+
+// r and c count from 0
+func (b Board) At(r, c int) int {
+	return b[r * Size + c]
+}
+
+func (b *Board) Set(r, c, v int) {
+	b[r * Size + c] = v
+}
+
+// how many cells are filled in
+func (b Board) Givens() (n int) {
+	for _, v := range b {
+		if v != 0 { n++ }
+	}
+	return n
+}
+
+func (b Board) String() string {
+	s, _ := b.Marshal(Grid)
+	return string(s)
+}
+
+// the box (0 to 8, row major) holding row r, column c
+func Box(r, c int) int {
+	return r / BoxSize * BoxSize + c / BoxSize
+}
+
+// an error naming the first cell whose digit is already given elsewhere
+// in its row, column or box. a blank board is valid, a valid board need
+// not be solvable.
+func (b Board) Validate() error {
+	var rows, cols, boxes [Size][Size + 1]bool
+	for i, v := range b {
+		r, c := i / Size, i % Size
+		switch {
+		case v < 0 || v > Size:
+			return fmt.Errorf("row %d, column %d: %d is not in [0, %d]", r + 1, c + 1, v, Size)
+		case v == 0:
+			continue
+		case rows[r][v]:
+			return fmt.Errorf("row %d, column %d: %d is already in row %d", r + 1, c + 1, v, r + 1)
+		case cols[c][v]:
+			return fmt.Errorf("row %d, column %d: %d is already in column %d", r + 1, c + 1, v, c + 1)
+		case boxes[Box(r, c)][v]:
+			return fmt.Errorf("row %d, column %d: %d is already in box %d", r + 1, c + 1, v, Box(r, c) + 1)
+		}
+		rows[r][v], cols[c][v], boxes[Box(r, c)][v] = true, true, true
+	}
+	return nil
+}
+
+// every cell filled and nothing repeated
+func (b Board) Solved() bool {
+	return b.Givens() == NumCells && b.Validate() == nil
+}
@@ -0,0 +1,325 @@
+package board
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// the text formats a Board can be read from and written to
+type Format string
+
+const (
+	// 81 characters on one line, row major, '.' or '0' for blanks:
+	//	53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79
+	Line Format = "line"
+
+	// nine rows of cells separated by spaces, with lines between the boxes:
+	//	5 3 . | . 7 . | . . .
+	//	6 . . | 1 9 5 | . . .
+	//	. 9 8 | . . . | . 6 .
+	//	------+-------+------
+	//	...
+	// when reading, any of '|', '+', '-' and spaces can be used as
+	// decoration and '.', '0' or '_' for blanks
+	Grid Format = "grid"
+
+	// SadMan Software Sudoku: nine rows of nine characters with '.' for
+	// blanks, optionally after #-comment lines and a [Puzzle] header
+	SDK Format = "sdk"
+
+	// SimpleSudoku: nine rows like 53.|.7.|... with ----------- between
+	// the bands, '.' or 'X' for blanks. a border of '|', '+' and '*' is
+	// fine when reading.
+	SS Format = "ss"
+
+	// {"cells": [5, 3, 0, ...]}, the 81 cells row major with 0 for blanks,
+	// like the cells of an alignment.Annotation
+	JSON Format = "json"
+)
+
+var Formats = []Format{Line, Grid, SDK, SS, JSON}
+
+// what went wrong reading a board. Row and Col count from 1 and point at
+// the offending cell, or are 0 if the problem isn't with one cell (a
+// missing row, say).
+type ParseError struct {
+	Format Format
+	Row, Col int
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	switch {
+	case e.Row > 0 && e.Col > 0:
+		return fmt.Sprintf("[Parse] %s: row %d, column %d: %s", e.Format, e.Row, e.Col, e.Msg)
+	case e.Row > 0:
+		return fmt.Sprintf("[Parse] %s: row %d: %s", e.Format, e.Row, e.Msg)
+	}
+	return fmt.Sprintf("[Parse] %s: %s", e.Format, e.Msg)
+}
+
+func parseError(f Format, row, col int, format string, args ...interface{}) *ParseError {
+	return &ParseError{f, row, col, fmt.Sprintf(format, args...)}
+}
+
+// the format for a file, by its extension: .sdk, .ss, .json, or .txt for
+// Line
+func FormatOf(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".sdk":
+		return SDK, nil
+	case ".ss":
+		return SS, nil
+	case ".json":
+		return JSON, nil
+	case ".txt":
+		return Line, nil
+	}
+	return "", fmt.Errorf("[FormatOf] can't tell the format of %s, expected .sdk, .ss, .json or .txt", path)
+}
+
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("[ParseFormat] unknown format %q, expected one of %v", s, Formats)
+}
+
+func Parse(data []byte, f Format) (b Board, err error) {
+	switch f {
+	case Line:
+		return parseLine(data)
+	case Grid:
+		return parseRows(f, textRows(data), func(r rune) bool {
+			return r == ' ' || r == '\t' || r == '|' || r == '+' || r == '-'
+		}, ".0_")
+	case SDK:
+		return parseRows(f, sdkRows(data), func(r rune) bool { return false }, ".")
+	case SS:
+		return parseRows(f, textRows(data), func(r rune) bool {
+			return r == '|' || r == '-' || r == '+' || r == '*' || r == ' ' || r == '\t'
+		}, ".Xx0")
+	case JSON:
+		return parseJSON(data)
+	}
+	return b, fmt.Errorf("[Parse] unknown format %q", f)
+}
+
+func parseLine(data []byte) (b Board, err error) {
+	s := strings.TrimSpace(string(data))
+	if n := len([]rune(s)); n != NumCells {
+		return b, parseError(Line, 0, 0, "expected %d characters, got %d", NumCells, n)
+	}
+	for i, ch := range []rune(s) {
+		v, ok := digit(ch, ".0")
+		if !ok {
+			return b, parseError(Line, i / Size + 1, i % Size + 1, "bad character %q", ch)
+		}
+		b[i] = v
+	}
+	return b, nil
+}
+
+// the value of a cell character, blanks are any of the runes in blank
+func digit(ch rune, blank string) (int, bool) {
+	switch {
+	case strings.ContainsRune(blank, ch):
+		return 0, true
+	case '1' <= ch && ch <= '9':
+		return int(ch - '0'), true
+	}
+	return 0, false
+}
+
+// the non-empty lines, trailing whitespace and carriage returns dropped
+func textRows(data []byte) (rows []string) {
+	for _, l := range strings.Split(string(data), "\n") {
+		if l = strings.TrimRight(l, " \t\r"); strings.TrimSpace(l) != "" {
+			rows = append(rows, l)
+		}
+	}
+	return rows
+}
+
+// the puzzle rows of an .sdk file: comments go, and if there is a
+// [Puzzle] section only its rows are kept
+func sdkRows(data []byte) (rows []string) {
+	in_puzzle, sections := false, false
+	for _, l := range textRows(data) {
+		l = strings.TrimSpace(l)
+		switch {
+		case strings.HasPrefix(l, "#"):
+		case strings.HasPrefix(l, "["):
+			sections = true
+			in_puzzle = strings.EqualFold(l, "[Puzzle]")
+		case in_puzzle || !sections:
+			rows = append(rows, l)
+		}
+	}
+	return rows
+}
+
+// reads Size rows of Size cells each. decoration runes are skipped, and a
+// line that is nothing but decoration (a box separator) isn't a row.
+func parseRows(f Format, lines []string, decoration func(rune) bool, blank string) (b Board, err error) {
+	r := 0
+	for _, l := range lines {
+		var cells []rune
+		for _, ch := range l {
+			if !decoration(ch) { cells = append(cells, ch) }
+		}
+		if len(cells) == 0 {
+			continue
+		}
+		if r == Size {
+			return b, parseError(f, r + 1, 0, "expected %d rows, found more: %q", Size, l)
+		}
+		for c, ch := range cells {
+			if c == Size {
+				return b, parseError(f, r + 1, 0, "expected %d cells, got %d in %q", Size, len(cells), l)
+			}
+			v, ok := digit(ch, blank)
+			if !ok {
+				return b, parseError(f, r + 1, c + 1, "bad character %q", ch)
+			}
+			b.Set(r, c, v)
+		}
+		if len(cells) < Size {
+			return b, parseError(f, r + 1, 0, "expected %d cells, got %d in %q", Size, len(cells), l)
+		}
+		r++
+	}
+	if r < Size {
+		return b, parseError(f, 0, 0, "expected %d rows, got %d", Size, r)
+	}
+	return b, nil
+}
+
+type jsonBoard struct {
+	Cells []int `json:"cells"`
+}
+
+func parseJSON(data []byte) (b Board, err error) {
+	var j jsonBoard
+	if err = json.Unmarshal(data, &j); err != nil {
+		return b, parseError(JSON, 0, 0, "%s", err)
+	}
+	if len(j.Cells) != NumCells {
+		return b, parseError(JSON, 0, 0, "expected %d cells, got %d", NumCells, len(j.Cells))
+	}
+	for i, v := range j.Cells {
+		if v < 0 || v > Size {
+			return b, parseError(JSON, i / Size + 1, i % Size + 1, "%d is not in [0, %d]", v, Size)
+		}
+		b[i] = v
+	}
+	return b, nil
+}
+
+func (b Board) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonBoard{b.Cells()})
+}
+
+func (b *Board) UnmarshalJSON(data []byte) (err error) {
+	*b, err = parseJSON(data)
+	return err
+}
+
+// the board in format f, ending in a newline
+func (b Board) Marshal(f Format) ([]byte, error) {
+	var buf bytes.Buffer
+	cell := func(r, c int, blank byte) byte {
+		if v := b.At(r, c); v != 0 {
+			return byte('0' + v)
+		}
+		return blank
+	}
+	switch f {
+	case Line:
+		for i := range b {
+			buf.WriteByte(cell(i / Size, i % Size, '.'))
+		}
+		buf.WriteByte('\n')
+	case Grid:
+		for r := 0; r < Size; r++ {
+			if r > 0 && r % BoxSize == 0 {
+				buf.WriteString("------+-------+------\n")
+			}
+			for c := 0; c < Size; c++ {
+				if c > 0 && c % BoxSize == 0 { buf.WriteString(" |") }
+				if c > 0 { buf.WriteByte(' ') }
+				buf.WriteByte(cell(r, c, '.'))
+			}
+			buf.WriteByte('\n')
+		}
+	case SDK:
+		for r := 0; r < Size; r++ {
+			for c := 0; c < Size; c++ {
+				buf.WriteByte(cell(r, c, '.'))
+			}
+			buf.WriteByte('\n')
+		}
+	case SS:
+		for r := 0; r < Size; r++ {
+			if r > 0 && r % BoxSize == 0 {
+				buf.WriteString("-----------\n")
+			}
+			for c := 0; c < Size; c++ {
+				if c > 0 && c % BoxSize == 0 { buf.WriteByte('|') }
+				buf.WriteByte(cell(r, c, '.'))
+			}
+			buf.WriteByte('\n')
+		}
+	case JSON:
+		j, err := json.Marshal(b)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(j)
+		buf.WriteByte('\n')
+	default:
+		return nil, fmt.Errorf("[Board.Marshal] unknown format %q", f)
+	}
+	return buf.Bytes(), nil
+}
+
+// reads the board at path in format f, or the one its extension says if f
+// is empty
+func Load(path string, f Format) (b Board, err error) {
+	if f == "" {
+		if f, err = FormatOf(path); err != nil {
+			return b, err
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return b, fmt.Errorf("[Load] could not read %s: %w", path, err)
+	}
+	if b, err = Parse(data, f); err != nil {
+		return b, fmt.Errorf("[Load] %s: %w", path, err)
+	}
+	return b, nil
+}
+
+// writes b to path in format f, or the one its extension says if f is empty
+func (b Board) Save(path string, f Format) (err error) {
+	if f == "" {
+		if f, err = FormatOf(path); err != nil {
+			return err
+		}
+	}
+	data, err := b.Marshal(f)
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("[Board.Save] could not write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package board
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const classic = "53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79"
+
+func classicBoard(t *testing.T) Board {
+	b, err := Parse([]byte(classic), Line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestParseLine(t *testing.T) {
+	b := classicBoard(t)
+	if b.At(0, 0) != 5 || b.At(0, 2) != 0 || b.At(8, 8) != 9 || b.Givens() != 30 {
+		t.Errorf("parsed wrong:\n%s", b)
+	}
+	zeros, err := Parse([]byte(strings.ReplaceAll(classic, ".", "0") + "\n"), Line)
+	if err != nil || zeros != b {
+		t.Errorf("'0' blanks and a trailing newline should parse the same: %v", err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	b := classicBoard(t)
+	for _, f := range Formats {
+		data, err := b.Marshal(f)
+		if err != nil {
+			t.Fatalf("%s: %s", f, err)
+		}
+		got, err := Parse(data, f)
+		if err != nil {
+			t.Fatalf("%s: %s\n%s", f, err, data)
+		}
+		if got != b {
+			t.Errorf("%s round trip changed the board:\n%s", f, data)
+		}
+	}
+}
+
+func TestWriters(t *testing.T) {
+	b := classicBoard(t)
+	want := map[Format]string{
+		Grid: "5 3 . | . 7 . | . . .\n6 . . | 1 9 5 | . . .\n. 9 8 | . . . | . 6 .\n" +
+			"------+-------+------\n",
+		SDK: "53..7....\n6..195...\n",
+		SS: "53.|.7.|...\n6..|195|...\n.98|...|.6.\n-----------\n",
+		JSON: `{"cells":[5,3,0,0,7,0,0,0,0,6,`,
+	}
+	for f, prefix := range want {
+		data, _ := b.Marshal(f)
+		if !strings.HasPrefix(string(data), prefix) {
+			t.Errorf("%s starts\n%s\nwant\n%s", f, data, prefix)
+		}
+	}
+}
+
+func TestParseFileFormats(t *testing.T) {
+	b := classicBoard(t)
+	inputs := map[Format]string{
+		SDK: "#ASadman\n#DA classic\n[Puzzle]\n53..7....\n6..195...\n.98....6.\n8...6...3\n4..8.3..1\n7...2...6\n.6....28.\n...419..5\n....8..79\n" +
+			"[State]\n123456789\n",
+		SS: "*-----------*\n|53.|.7.|...|\n|6..|195|...|\n|.98|...|.6.|\n|---+---+---|\n|8..|.6.|..3|\n|4..|8.3|..1|\n|7..|.2.|..6|\n" +
+			"|---+---+---|\n|.6.|...|28.|\n|...|419|..5|\n|...|.8.|.79|\n*-----------*\n",
+		Grid: "+-------+-------+-------+\r\n| 5 3 _ | _ 7 _ | _ _ _ |\r\n| 6 _ _ | 1 9 5 | _ _ _ |\r\n| _ 9 8 | _ _ _ | _ 6 _ |\r\n" +
+			"+-------+-------+-------+\r\n| 8 _ _ | _ 6 _ | _ _ 3 |\r\n| 4 _ _ | 8 _ 3 | _ _ 1 |\r\n| 7 _ _ | _ 2 _ | _ _ 6 |\r\n" +
+			"+-------+-------+-------+\r\n| _ 6 _ | _ _ _ | 2 8 _ |\r\n| _ _ _ | 4 1 9 | _ _ 5 |\r\n| _ _ _ | _ 8 _ | _ 7 9 |\r\n+-------+-------+-------+\r\n",
+		JSON: `{"cells": [5,3,0,0,7,0,0,0,0, 6,0,0,1,9,5,0,0,0, 0,9,8,0,0,0,0,6,0, 8,0,0,0,6,0,0,0,3,
+			4,0,0,8,0,3,0,0,1, 7,0,0,0,2,0,0,0,6, 0,6,0,0,0,0,2,8,0, 0,0,0,4,1,9,0,0,5, 0,0,0,0,8,0,0,7,9]}`,
+	}
+	for f, in := range inputs {
+		got, err := Parse([]byte(in), f)
+		if err != nil {
+			t.Errorf("%s: %s", f, err)
+			continue
+		}
+		if got != b {
+			t.Errorf("%s parsed to\n%s", f, got)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	grid, _ := classicBoard(t).Marshal(Grid)
+	bad_grid := strings.Replace(string(grid), "1 9 5", "1 x 5", 1)
+	sdk, _ := classicBoard(t).Marshal(SDK)
+	short_sdk := strings.Replace(string(sdk), "4..8.3..1", "4..8.3..", 1)
+	cases := []struct {
+		f Format
+		in string
+		row, col int
+	}{
+		{Line, strings.Replace(classic, "195", "1?5", 1), 2, 5},
+		{Line, classic[:80], 0, 0},
+		{Grid, bad_grid, 2, 5},
+		{SDK, short_sdk, 5, 0},
+		{SDK, string(sdk) + "123456789\n", 10, 0},
+		{SS, "53.|.7.|...\n", 0, 0},
+		{JSON, `{"cells": [` + strings.Repeat("0,", 40) + "12," + strings.Repeat("0,", 39) + "0]}", 5, 5},
+		{JSON, `{"cells": [1, 2]}`, 0, 0},
+		{JSON, `[1, 2`, 0, 0},
+	}
+	for _, c := range cases {
+		_, err := Parse([]byte(c.in), c.f)
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("%s %q: expected a ParseError, got %v", c.f, c.in, err)
+			continue
+		}
+		if pe.Row != c.row || pe.Col != c.col {
+			t.Errorf("%s: error at row %d, column %d, want %d, %d: %s", c.f, pe.Row, pe.Col, c.row, c.col, err)
+		}
+	}
+	if _, err := Parse([]byte(classic), "csv"); err == nil {
+		t.Errorf("unknown formats should not parse")
+	}
+}
+
+func TestLoadSave(t *testing.T) {
+	b := classicBoard(t)
+	dir := t.TempDir()
+	for _, name := range []string{"a.sdk", "a.ss", "a.json", "a.txt"} {
+		path := filepath.Join(dir, name)
+		if err := b.Save(path, ""); err != nil {
+			t.Fatal(err)
+		}
+		got, err := Load(path, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != b {
+			t.Errorf("%s came back as\n%s", name, got)
+		}
+	}
+	if err := b.Save(filepath.Join(dir, "a.grid"), Grid); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(filepath.Join(dir, "a.grid"), ""); err == nil {
+		t.Errorf("unknown extension with no format should fail")
+	}
+	if got, err := Load(filepath.Join(dir, "a.grid"), Grid); err != nil || got != b {
+		t.Errorf("grid with its format given: %v", err)
+	}
+}
+
+func TestFromCells(t *testing.T) {
+	b := classicBoard(t)
+	got, err := FromCells(b.Cells())
+	if err != nil || got != b {
+		t.Errorf("cells round trip: %v", err)
+	}
+	cells := b.Cells()
+	cells[10] = 10
+	if _, err := FromCells(cells); err == nil || !strings.Contains(err.Error(), "row 2, column 2") {
+		t.Errorf("bad cell should be pointed at: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	b := classicBoard(t)
+	if err := b.Validate(); err != nil {
+		t.Errorf("classic puzzle: %s", err)
+	}
+	if b.Solved() {
+		t.Errorf("a puzzle with blanks isn't solved")
+	}
+	for _, c := range []struct {
+		r, c, v int
+		want string
+	}{
+		{0, 2, 5, "row 1, column 3: 5 is already in row 1"},
+		{8, 0, 5, "row 9, column 1: 5 is already in column 1"},
+		{1, 1, 8, "row 3, column 3: 8 is already in box 1"},	// found at the given 8, it comes later
+	} {
+		bad := b
+		bad.Set(c.r, c.c, c.v)
+		if err := bad.Validate(); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%d at (%d, %d): got %v, want %q", c.v, c.r + 1, c.c + 1, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// edgedetector fits a whole lattice of lines to the board at once
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/twolfe18/sudoku/alignment"
+	"github.com/twolfe18/sudoku/config"
+	"github.com/twolfe18/sudoku/debugsink"
+	"github.com/twolfe18/sudoku/geometry"
+	"github.com/twolfe18/sudoku/imaging"
+)
+
+func main() {
+	cfg, args, err := config.ParseConfig(os.Args[0], os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+		os.Exit(1)
+	}
+	inputf := "img/clean_256_256.png"
+	switch len(args) {
+	case 0:
+	case 1:
+		inputf = args[0]
+	default:
+		fmt.Fprintf(os.Stderr, "[main] expected at most one image, got %v\n", args)
+		os.Exit(1)
+	}
+
+	img, err := imaging.OpenImage(inputf)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+		os.Exit(1)
+	}
+	debug, err := cfg.DebugSink()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+		os.Exit(1)
+	}
+	var anim *alignment.Animation
+	if cfg.DebugGIF != "" {
+		anim = alignment.NewAnimation(img)
+		debug = debugsink.Tee{debug, anim}
+	}
+	ed := alignment.NewEdgeDetector(geometry.NewFloat64Rectangle(img.Bounds()), cfg.EdgeDetector)
+
+	// draw out ED right after creating it
+	if debug.Enabled() {
+		if err = debug.Image("after_ed_init", ed.Draw(img)); err != nil {
+			fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if ed, err = ed.AlignTo(img, debug); err != nil {
+		fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+		os.Exit(1)
+	}
+	output := ed.Draw(img)
+	svg := ed.SVG
+	href := ""
+	if cfg.SVGLink { href = inputf }
+
+	// then bend the lines for warped pages, straight curves if not asked to
+	grid := alignment.NewCurvedGrid(ed)
+	if cfg.EdgeDetector.BendIterations > 0 {
+		if grid, err = grid.AlignTo(img, debug); err != nil {
+			fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+			os.Exit(1)
+		}
+		output = grid.Draw(img)
+		svg = grid.SVG
+	}
+	if err = imaging.SaveImage(output, cfg.Output); err != nil {
+		fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+		os.Exit(1)
+	}
+	if anim != nil {
+		if err = anim.Save(cfg.DebugGIF); err != nil {
+			fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+			os.Exit(1)
+		}
+	}
+	if cfg.SVG != "" {
+		if err = svg(img, href).Save(cfg.SVG); err != nil {
+			fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+			os.Exit(1)
+		}
+	}
+	if cfg.Rectify != "" {
+		if err = imaging.SaveImage(grid.Rectify(img, alignment.RectifiedCell), cfg.Rectify); err != nil {
+			fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// evaluate aligns every annotated image in a directory and reports
+// corner error and cell IoU against the annotations
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/twolfe18/sudoku/config"
+	"github.com/twolfe18/sudoku/evaluation"
+)
+
+func main() {
+	dir := flag.String("dir", "img", "directory of images with <image>.json annotations")
+	ablate := flag.String("ablate", "", "potential terms to switch off one at a time and compare: cross, parallel, orthogonal, spacing")
+	get := config.Flags(flag.CommandLine)
+	flag.Parse()
+
+	cfg, err := get()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+		os.Exit(1)
+	}
+	imgs, err := evaluation.LoadDataset(*dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+		os.Exit(1)
+	}
+	if *ablate != "" {
+		terms, err := evaluation.ParseAblations(*ablate)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+			os.Exit(1)
+		}
+		results, err := evaluation.Ablate(cfg.EdgeDetector, imgs, terms)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+			os.Exit(1)
+		}
+		evaluation.PrintAblations(os.Stdout, results)
+		return
+	}
+	r, err := evaluation.EvaluateParams(cfg.EdgeDetector, imgs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+		os.Exit(1)
+	}
+	r.Print(os.Stdout)
+}
@@ -0,0 +1,114 @@
+// simplelineopt places lines on the board one at a time and hill climbs
+// each one onto the grid by itself
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+
+	"github.com/twolfe18/sudoku/config"
+	"github.com/twolfe18/sudoku/drawing"
+	"github.com/twolfe18/sudoku/geometry"
+	"github.com/twolfe18/sudoku/imaging"
+	"github.com/twolfe18/sudoku/lines"
+	"github.com/twolfe18/sudoku/logging"
+)
+
+// the loop here is the lines package's optimizer, so it logs as that
+var log = logging.For("lines")
+
+func main() {
+	cfg, args, err := config.ParseConfig(os.Args[0], os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+		os.Exit(1)
+	}
+	inputf := "img/clean_256_256.png"
+	switch len(args) {
+	case 0:
+	case 1:
+		inputf = args[0]
+	default:
+		fmt.Fprintf(os.Stderr, "[main] expected at most one image, got %v\n", args)
+		os.Exit(1)
+	}
+
+	img, err := imaging.OpenImage(inputf)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+		os.Exit(1)
+	}
+	debug, err := cfg.DebugSink()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+		os.Exit(1)
+	}
+	if err = debug.Image("after_init", img); err != nil {
+		fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+		os.Exit(1)
+	}
+
+	p := cfg.LineOpt
+	found := make([]geometry.Line, 0)
+	for len(found) < p.NumLines {
+
+		// randomly place a line on the board
+		b := geometry.NewFloat64Rectangle(img.Bounds())
+		left := geometry.RandomPointBetween(b.Min, b.Max)
+		right := geometry.RandomPointBetween(b.Min, b.Max)
+		i := len(found)
+		found = append(found, geometry.Line{Left: left, Right: right, Radius: p.LineRadius})
+
+		// TODO mask off current lines
+
+		// see where it goes to
+		for iter := 0; iter < p.MaxIter; iter++ {
+			newline := lines.LocalOptimizePotential(found[i], img, p)
+			if found[i].Equals(newline) {
+				log.Debug("converged", "line", i, "iter", iter)
+				break
+			}
+			found[i] = newline
+			if !debug.Enabled() { continue }
+			cpy := imaging.CopyImage(img)
+			for _,l := range found {
+				drawing.Line(cpy, l, 1.5, color.RGBA{255, 0, 0, 255})
+			}
+			name := fmt.Sprintf("line.%d.%03d", i, iter)
+			if err = debug.Image(name, cpy); err != nil {
+				fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+				os.Exit(1)
+			}
+			if err = debug.Data(name + ".potential", lines.LinePotential(found[i], img)); err != nil {
+				fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	output := imaging.CopyImage(img)
+	for _, l := range found {
+		drawing.Line(output, l, 1.5, color.RGBA{255, 0, 0, 255})
+	}
+	if err = imaging.SaveImage(output, cfg.Output); err != nil {
+		fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+		os.Exit(1)
+	}
+
+	if cfg.SVG != "" {
+		s := drawing.NewSVG(img.Bounds())
+		if cfg.SVGLink {
+			s.ImageHref = inputf
+		} else {
+			s.Image = img
+		}
+		for i, l := range found {
+			s.AddLine(l, 1.0, color.RGBA{255, 0, 0, 255}, fmt.Sprintf("%d: %.1f", i, lines.LinePotential(l, img)))
+		}
+		if err = s.Save(cfg.SVG); err != nil {
+			fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// solve reads puzzles one per line (81 characters, '.' or '0' for blanks)
+// from a file or stdin and writes each one's solution, status and timing
+// in the same order, with a summary at the end
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+
+	"github.com/twolfe18/sudoku/logging"
+	"github.com/twolfe18/sudoku/solver"
+)
+
+func main() {
+	name := flag.String("solver", "bitmask", fmt.Sprintf("which solver: %v", solver.Names()))
+	workers := flag.Int("workers", runtime.NumCPU(), "puzzles solved at once")
+	out := flag.String("out", "", "write solutions here instead of stdout")
+	flag.Var(logging.Flag{}, "log", logging.Usage)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] [puzzles.txt]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	s, err := solver.New(*name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+		os.Exit(1)
+	}
+	var r io.Reader = os.Stdin
+	if flag.NArg() > 0 && flag.Arg(0) != "-" {
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[main] could not open %s: %s\n", flag.Arg(0), err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		r = f
+	}
+	var w io.Writer = os.Stdout
+	var outf *os.File
+	if *out != "" {
+		if outf, err = os.Create(*out); err != nil {
+			fmt.Fprintf(os.Stderr, "[main] could not open %s: %s\n", *out, err)
+			os.Exit(1)
+		}
+		w = outf
+	}
+
+	sum, err := solver.Batch(r, w, s, *workers)
+	// the summary and any errors go to stderr so stdout is only solutions.
+	// os.Exit skips defers, so the output is closed by hand before it.
+	sum.Print(os.Stderr)
+	if outf != nil {
+		if cerr := outf.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("could not close %s: %w", *out, cerr)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+		os.Exit(1)
+	}
+}
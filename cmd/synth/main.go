@@ -0,0 +1,89 @@
+// synth renders sudoku boards with known corners and digits, writing
+// each image next to its annotation
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/twolfe18/sudoku/alignment"
+	"github.com/twolfe18/sudoku/imaging"
+	"github.com/twolfe18/sudoku/logging"
+	"github.com/twolfe18/sudoku/synth"
+)
+
+func main() {
+	p := synth.DefaultSynthParams()
+	flag.IntVar(&p.Size, "size", p.Size, "output side in pixels")
+	flag.Float64Var(&p.BoardFraction, "board", p.BoardFraction, "board side as a fraction of the image")
+	flag.Float64Var(&p.LineThickness, "line", p.LineThickness, "thickness of lines between cells (pixels)")
+	flag.Float64Var(&p.BoxLineThickness, "boxline", p.BoxLineThickness, "thickness of lines between boxes (pixels)")
+	flag.StringVar(&p.Font, "font", p.Font, "digit font: 5x7 or 3x5")
+	flag.Float64Var(&p.DigitHeight, "digit", p.DigitHeight, "digit height as a fraction of the cell")
+	flag.Float64Var(&p.Rotation, "rotation", p.Rotation, "max rotation (degrees)")
+	flag.Float64Var(&p.Perspective, "perspective", p.Perspective, "max corner displacement, fraction of the board")
+	flag.IntVar(&p.Blur, "blur", p.Blur, "box blur radius (pixels)")
+	flag.Float64Var(&p.Noise, "noise", p.Noise, "std deviation of pixel noise")
+	flag.Float64Var(&p.Gradient, "gradient", p.Gradient, "strength of the lighting gradient in [0,1]")
+	flag.IntVar(&p.Clutter, "clutter", p.Clutter, "number of background strokes")
+	puzzle := flag.String("puzzle", "53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79", "81 character puzzle")
+	puzzles := flag.String("puzzles", "", "file with one 81 character puzzle per line, overrides -puzzle")
+	n := flag.Int("n", 1, "images per puzzle")
+	out := flag.String("out", "img/synth", "output directory")
+	seed := flag.Int64("seed", 1, "random seed")
+	flag.Var(logging.Flag{}, "log", logging.Usage)
+	flag.Parse()
+
+	lines := []string{*puzzle}
+	if *puzzles != "" {
+		f, err := os.Open(*puzzles)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[main] could not open %s: %s\n", *puzzles, err)
+			os.Exit(1)
+		}
+		lines = nil
+		r := bufio.NewReader(f)
+		for {
+			l, err := r.ReadString('\n')
+			if strings.TrimSpace(l) != "" { lines = append(lines, l) }
+			if err != nil { break }
+		}
+		f.Close()
+	}
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "[main] could not make %s: %s\n", *out, err)
+		os.Exit(1)
+	}
+
+	rng := rand.New(rand.NewSource(*seed))
+	k := 0
+	for li, l := range lines {
+		cells, err := synth.ParsePuzzleLine(l)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[main] puzzle %d: %s\n", li + 1, err)
+			os.Exit(1)
+		}
+		for i := 0; i < *n; i++ {
+			img, a, err := synth.Render(cells, p, rng)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+				os.Exit(1)
+			}
+			path := filepath.Join(*out, fmt.Sprintf("synth_%04d.png", k))
+			if err = imaging.SaveImage(img, path); err != nil {
+				fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+				os.Exit(1)
+			}
+			if err = a.Save(alignment.AnnotationPath(path)); err != nil {
+				fmt.Fprintf(os.Stderr, "[main] could not save annotation for %s: %s\n", path, err)
+				os.Exit(1)
+			}
+			k++
+		}
+	}
+}
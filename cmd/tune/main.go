@@ -0,0 +1,73 @@
+// tune searches the aligner's parameters over a directory of annotated
+// images and reports the accuracy and runtime of each setting
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+
+	"github.com/twolfe18/sudoku/config"
+	"github.com/twolfe18/sudoku/evaluation"
+)
+
+func main() {
+	dir := flag.String("dir", "img", "directory of images with <image>.json annotations")
+	grid := flag.String("grid", "ed.greedyness=1:4:4,ed.num_proposals=25:100:4", "parameters to search, name=lo:hi:steps,...")
+	random := flag.Int("random", 0, "if > 0, sample this many settings instead of the full grid")
+	seed := flag.Int64("seed", 1, "random seed for -random, the aligner's is ed.seed in -config")
+	runs := flag.Int("runs", 3, "times to align each image per setting, with seeds ed.seed, ed.seed+1, ...")
+	// -config and the ed.* flags set the parameters that are not searched over
+	get := config.Flags(flag.CommandLine)
+	flag.Parse()
+
+	base, err := get()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+		os.Exit(1)
+	}
+	ranges, err := evaluation.ParseParamRanges(*grid)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+		os.Exit(1)
+	}
+	imgs, err := evaluation.LoadDataset(*dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+		os.Exit(1)
+	}
+
+	var settings []evaluation.Setting
+	if *random > 0 {
+		settings = evaluation.RandomSettings(ranges, *random, rand.New(rand.NewSource(*seed)))
+	} else {
+		settings = evaluation.GridSettings(ranges)
+	}
+	fmt.Fprintf(os.Stderr, "[main] trying %d settings on %d images\n", len(settings), len(imgs))
+
+	results := make([]evaluation.TuneResult, 0, len(settings))
+	for i, s := range settings {
+		cfg, err := s.Apply(base)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[main] skipping %s: %s\n", s, err)
+			continue
+		}
+		r, spread, err := evaluation.EvaluateRuns(cfg.EdgeDetector, imgs, *runs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[main] %s\n", err)
+			os.Exit(1)
+		}
+		results = append(results, evaluation.TuneResult{Setting: s, Report: r, Spread: spread})
+		fmt.Fprintf(os.Stderr, "[main] %d/%d\t%s\tmean=%.2f±%.2fpx max=%.2fpx iou=%.3f %.2fs/img\n",
+			i+1, len(settings), s, r.MeanCornerError, spread, r.MaxCornerError, r.MeanCellIoU, r.MeanSeconds)
+	}
+
+	sort.Sort(evaluation.ByMeanError(results))
+	fmt.Printf("\n%10s %10s %10s %10s %10s   %s\n", "mean(px)", "spread", "max(px)", "cell IoU", "sec/img", "setting")
+	for _, t := range results {
+		r := t.Report
+		fmt.Printf("%10.2f %10.2f %10.2f %10.3f %10.2f   %s\n", r.MeanCornerError, t.Spread, r.MaxCornerError, r.MeanCellIoU, r.MeanSeconds, t.Setting)
+	}
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/twolfe18/sudoku/alignment"
+	"github.com/twolfe18/sudoku/debugsink"
+	"github.com/twolfe18/sudoku/lines"
+	"github.com/twolfe18/sudoku/logging"
+)
+
+// Config is the on-disk format, one section per line finder
+type Config struct {
+	LineOpt lines.Params `json:"line_opt"`
+	EdgeDetector alignment.EdgeDetectorParams `json:"edge_detector"`
+
+	// the input image with the fitted lines drawn over it
+	Output string `json:"output,omitempty"`
+
+	// where to write debugging artifacts, nothing is written if empty
+	DebugDir string `json:"debug_dir,omitempty"`
+	// an animated GIF of the EdgeDetector's alignment, skipped if empty
+	DebugGIF string `json:"debug_gif,omitempty"`
+
+	// the fitted lines as an SVG, skipped if empty. the source image is
+	// embedded unless SVGLink is set, then it is linked by path.
+	SVG string `json:"svg,omitempty"`
+	SVGLink bool `json:"svg_link,omitempty"`
+
+	// the board unwarped into square cells, skipped if empty
+	Rectify string `json:"rectify,omitempty"`
+}
+
+func DefaultConfig() (c Config) {
+	c.LineOpt = lines.DefaultParams()
+	c.EdgeDetector = alignment.DefaultEdgeDetectorParams()
+	c.Output = "output.png"
+	return c
+}
+
+// reads a JSON config on top of the defaults, so a file only needs to
+// mention the knobs it wants to change. only JSON, and a key that isn't a
+// knob (a typo, say) is an error rather than quietly ignored.
+func LoadConfig(path string) (c Config, err error) {
+	c = DefaultConfig()
+	if err = c.load(path); err != nil {
+		return c, err
+	}
+	return c, c.Validate()
+}
+
+func (c *Config) load(path string) error {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("[LoadConfig] could not read %s: %s", path, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(c); err != nil {
+		return fmt.Errorf("[LoadConfig] could not parse %s: %s", path, err)
+	}
+	return nil
+}
+
+// every field gets a flag named <section>.<json name>
+func (c *Config) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.Output, "out", c.Output, "write the image with the fitted lines over it here")
+	fs.StringVar(&c.DebugDir, "debug_dir", c.DebugDir, "directory for debugging images and data (off if empty)")
+	fs.StringVar(&c.DebugGIF, "debug_gif", c.DebugGIF, "animated GIF of the alignment (off if empty)")
+	fs.StringVar(&c.SVG, "svg", c.SVG, "write the fitted lines to this SVG (off if empty)")
+	fs.BoolVar(&c.SVGLink, "svg_link", c.SVGLink, "link the source image from the SVG instead of embedding it")
+	fs.StringVar(&c.Rectify, "rectify", c.Rectify, "write the board, unwarped into square cells, to this image (off if empty)")
+
+	p := &c.LineOpt
+	fs.Float64Var(&p.LambdaDTheta, "lineopt.lambda_dtheta", p.LambdaDTheta, "penalty per degree of rotation")
+	fs.Float64Var(&p.DeltaDTheta, "lineopt.delta_dtheta", p.DeltaDTheta, "rotation step (degrees)")
+	fs.Float64Var(&p.MaxDTheta, "lineopt.max_dtheta", p.MaxDTheta, "largest rotation tried (degrees)")
+	fs.Float64Var(&p.LambdaDX, "lineopt.lambda_dx", p.LambdaDX, "penalty per pixel of x shift")
+	fs.Float64Var(&p.DeltaDX, "lineopt.delta_dx", p.DeltaDX, "x shift step (pixels)")
+	fs.Float64Var(&p.MaxDX, "lineopt.max_dx", p.MaxDX, "largest x shift tried (pixels)")
+	fs.Float64Var(&p.LambdaDY, "lineopt.lambda_dy", p.LambdaDY, "penalty per pixel of y shift")
+	fs.Float64Var(&p.DeltaDY, "lineopt.delta_dy", p.DeltaDY, "y shift step (pixels)")
+	fs.Float64Var(&p.MaxDY, "lineopt.max_dy", p.MaxDY, "largest y shift tried (pixels)")
+	fs.IntVar(&p.NumLines, "lineopt.num_lines", p.NumLines, "lines to place on the board")
+	fs.IntVar(&p.MaxIter, "lineopt.max_iter", p.MaxIter, "optimization steps per line")
+	fs.Float64Var(&p.LineRadius, "lineopt.line_radius", p.LineRadius, "std deviation of each line")
+
+	e := &c.EdgeDetector
+	fs.Float64Var(&e.LineRadius, "ed.line_radius", e.LineRadius, "std deviation of each grid line")
+	fs.Float64Var(&e.ParallelWeight, "ed.parallel_weight", e.ParallelWeight, "penalty for lines in a family not being parallel")
+	fs.Float64Var(&e.OrthogonalWeight, "ed.orthogonal_weight", e.OrthogonalWeight, "penalty for the two families not being at right angles")
+	fs.Float64Var(&e.SpacingWeight, "ed.spacing_weight", e.SpacingWeight, "penalty for uneven spacing within a family")
+	fs.Float64Var(&e.CrossWeight, "ed.cross_weight", e.CrossWeight, "reward for dark crosses at lattice intersections (0 is off)")
+	fs.Float64Var(&e.CrossArm, "ed.cross_arm", e.CrossArm, "arm length of the cross template (pixels)")
+	fs.UintVar(&e.NumProposals, "ed.num_proposals", e.NumProposals, "proposals per iteration")
+	fs.Float64Var(&e.Greedyness, "ed.greedyness", e.Greedyness, "0 is uniform choice, infinity is perfectly greedy")
+	fs.Float64Var(&e.ProposalVariance, "ed.proposal_variance", e.ProposalVariance, "size of random steps")
+	fs.Float64Var(&e.Variance.Rotate, "ed.variance.rotate", e.Variance.Rotate, "rotation (degrees) per unit of proposal_variance")
+	fs.Float64Var(&e.Variance.ShiftX, "ed.variance.shift_x", e.Variance.ShiftX, "left-right shift (pixels) per unit of proposal_variance")
+	fs.Float64Var(&e.Variance.ShiftY, "ed.variance.shift_y", e.Variance.ShiftY, "up-down shift (pixels) per unit of proposal_variance")
+	fs.Float64Var(&e.Variance.ScaleX, "ed.variance.scale_x", e.Variance.ScaleX, "horizontal stretch (fraction) per unit of proposal_variance")
+	fs.Float64Var(&e.Variance.ScaleY, "ed.variance.scale_y", e.Variance.ScaleY, "vertical stretch (fraction) per unit of proposal_variance")
+	fs.Float64Var(&e.Variance.Normal, "ed.variance.normal", e.Variance.Normal, "per_line shift along each line's normal (pixels) per unit of proposal_variance")
+	fs.Float64Var(&e.IndependentScale, "ed.independent_scale", e.IndependentScale, "per-line share of each step")
+	fs.StringVar(&e.ProposalMode, "ed.proposal_mode", e.ProposalMode, "joint, coordinate, laplace, spike_slab or per_line")
+	fs.Float64Var(&e.SpikeProb, "ed.spike_prob", e.SpikeProb, "chance each part of a spike_slab step is zero")
+	fs.Float64Var(&e.PriorWeight, "ed.prior_weight", e.PriorWeight, "weight of the step prior when choosing a proposal")
+	fs.Float64Var(&e.Padding, "ed.padding", e.Padding, "distance from border to initial grid")
+	fs.IntVar(&e.NumLines, "ed.num_lines", e.NumLines, "lines in each direction")
+	fs.Float64Var(&e.Crappyness, "ed.crappyness", e.Crappyness, "initial perturbation, in proposal variances")
+	fs.IntVar(&e.NumIterations, "ed.num_iterations", e.NumIterations, "hill climbing iterations")
+	fs.IntVar(&e.BendIterations, "ed.bend_iterations", e.BendIterations, "hill climbing iterations bending the lines for warped pages (0 keeps them straight)")
+	fs.Float64Var(&e.BendVariance, "ed.bend_variance", e.BendVariance, "how far a line's middle moves per bend proposal (pixels)")
+	fs.Float64Var(&e.BendWeight, "ed.bend_weight", e.BendWeight, "penalty per squared pixel of bend")
+	fs.Int64Var(&e.Seed, "ed.seed", e.Seed, "random seed for the initial grid and every proposal")
+}
+
+// adds -config, -log and every Config flag to fs, for commands that have
+// flags of their own. once fs is parsed, the returned func reads the
+// -config file, if any, then applies the flags given explicitly on top of
+// it and validates the result.
+func Flags(fs *flag.FlagSet) func() (Config, error) {
+	c := DefaultConfig()
+	path := fs.String("config", "", "JSON file with line finder parameters (JSON only, unknown keys are an error)")
+	fs.Var(logging.Flag{}, "log", logging.Usage)
+	c.RegisterFlags(fs)
+	return func() (Config, error) {
+		if *path != "" {
+			// remember what was set on the command line, the file will clobber it
+			explicit := make(map[string]string)
+			fs.Visit(func(f *flag.Flag) { explicit[f.Name] = f.Value.String() })
+			if err := c.load(*path); err != nil {
+				return c, err
+			}
+			for k, v := range explicit {
+				if err := fs.Set(k, v); err != nil {
+					return c, fmt.Errorf("[Flags] could not reapply -%s=%s over %s: %w", k, v, *path, err)
+				}
+			}
+		}
+		return c, c.Validate()
+	}
+}
+
+// parses command line args with Flags. rest is what's left after the
+// flags, the input image for the line finders.
+func ParseConfig(name string, args []string) (c Config, rest []string, err error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	get := Flags(fs)
+	if err = fs.Parse(args); err != nil {
+		return DefaultConfig(), nil, err
+	}
+	c, err = get()
+	return c, fs.Args(), err
+}
+
+// a sink writing to DebugDir, or one that drops everything
+func (c Config) DebugSink() (debugsink.Sink, error) {
+	if c.DebugDir == "" {
+		return debugsink.Nop{}, nil
+	}
+	return debugsink.NewDirSink(c.DebugDir)
+}
+
+func (c Config) Validate() error {
+	if err := c.LineOpt.Validate(); err != nil {
+		return err
+	}
+	return c.EdgeDetector.Validate()
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/twolfe18/sudoku/alignment"
+)
+
+func writeConfig(t *testing.T, body string) string {
+	path := filepath.Join(t.TempDir(), "cfg.json")
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigKeepsDefaults(t *testing.T) {
+	path := writeConfig(t, `{"edge_detector": {"greedyness": 7.5}}`)
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.EdgeDetector.Greedyness != 7.5 {
+		t.Errorf("greedyness from the file: got %g", c.EdgeDetector.Greedyness)
+	}
+	want := DefaultConfig()
+	if c.EdgeDetector.NumProposals != want.EdgeDetector.NumProposals || c.LineOpt != want.LineOpt || c.Output != want.Output {
+		t.Errorf("knobs the file doesn't mention should keep their defaults: %+v", c)
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	path := writeConfig(t, `{"edge_detector": {"greedyness": 7.5, "num_proposals": 10}, "svg": "file.svg"}`)
+	c, rest, err := ParseConfig("test", []string{"-ed.num_proposals", "20", "-config", path, "-log", "alignment=info", "board.png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := c.EdgeDetector
+	switch {
+	case e.NumProposals != 20:
+		t.Errorf("the flag should win over the file, got num_proposals %d", e.NumProposals)
+	case e.Greedyness != 7.5 || c.SVG != "file.svg":
+		t.Errorf("values only in the file should be kept: greedyness %g, svg %q", e.Greedyness, c.SVG)
+	case e.LineRadius != alignment.DefaultEdgeDetectorParams().LineRadius:
+		t.Errorf("values in neither should be the defaults: line_radius %g", e.LineRadius)
+	}
+	if len(rest) != 1 || rest[0] != "board.png" {
+		t.Errorf("positional args: %v", rest)
+	}
+
+	// flags alone, no file
+	if c, _, err = ParseConfig("test", []string{"-ed.greedyness", "3"}); err != nil || c.EdgeDetector.Greedyness != 3.0 {
+		t.Errorf("greedyness from a flag: %g, %v", c.EdgeDetector.Greedyness, err)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	for _, args := range [][]string{
+		{"-ed.line_radius", "-1"},
+		{"-lineopt.line_radius", "0"},
+		{"-ed.spike_prob", "1.5"},
+		{"-ed.proposal_mode", "sideways"},
+	} {
+		if _, _, err := ParseConfig("test", args); err == nil {
+			t.Errorf("%v should be rejected", args)
+		}
+	}
+	path := writeConfig(t, `{"edge_detector": {"line_radius": -2}}`)
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "line_radius") {
+		t.Errorf("a negative radius in a file should be rejected, got %v", err)
+	}
+	if _, _, err := ParseConfig("test", []string{"-config", writeConfig(t, `{"edge_detector": `)}); err == nil {
+		t.Errorf("a broken file should be an error")
+	}
+	typo := writeConfig(t, `{"edge_detector": {"greedines": 3}}`)
+	if _, err := LoadConfig(typo); err == nil || !strings.Contains(err.Error(), "greedines") {
+		t.Errorf("a misspelled key should be an error, got %v", err)
+	}
+}
+
+// a command with flags of its own, like tune, gets the same precedence
+func TestFlags(t *testing.T) {
+	path := writeConfig(t, `{"edge_detector": {"greedyness": 7.5, "num_proposals": 10}}`)
+	fs := flag.NewFlagSet("tune", flag.ContinueOnError)
+	dir := fs.String("dir", "img", "")
+	get := Flags(fs)
+	if err := fs.Parse([]string{"-dir", "boards", "-config", path, "-ed.num_proposals", "30"}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *dir != "boards" || c.EdgeDetector.NumProposals != 30 || c.EdgeDetector.Greedyness != 7.5 {
+		t.Errorf("dir %q, num_proposals %d, greedyness %g", *dir, c.EdgeDetector.NumProposals, c.EdgeDetector.Greedyness)
+	}
+}
@@ -0,0 +1,153 @@
+// Package debugsink collects debugging output (overlay images, potentials,
+// proposal sets) from the line finders. Nothing is written unless a caller
+// hands in a Sink that wants it.
+package debugsink
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/twolfe18/sudoku/imaging"
+)
+
+type Sink interface {
+	// producers should skip building artifacts entirely when this is false
+	Enabled() bool
+
+	Image(name string, img image.Image) error
+
+	// anything encoding/json can handle
+	Data(name string, v interface{}) error
+}
+
+// a Sink that only wants Data (an animation built from the steps, say) can
+// implement this and return false, so producers don't render overlays for it
+type ImageWanter interface {
+	WantsImages() bool
+}
+
+// whether s is enabled and would keep an Image, sinks that don't implement
+// ImageWanter want them
+func WantsImages(s Sink) bool {
+	if !s.Enabled() { return false }
+	if w, ok := s.(ImageWanter); ok {
+		return w.WantsImages()
+	}
+	return true
+}
+
+// OrNop lets callers pass a nil Sink
+func OrNop(s Sink) Sink {
+	if s == nil {
+		return Nop{}
+	}
+	return s
+}
+
+// drops everything
+type Nop struct{}
+
+func (Nop) Enabled() bool { return false }
+func (Nop) Image(name string, img image.Image) error { return nil }
+func (Nop) Data(name string, v interface{}) error { return nil }
+
+// hands everything to each of its sinks, stopping at the first error
+type Tee []Sink
+
+func (t Tee) Enabled() bool {
+	for _, s := range t {
+		if s.Enabled() {
+			return true
+		}
+	}
+	return false
+}
+
+func (t Tee) WantsImages() bool {
+	for _, s := range t {
+		if WantsImages(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t Tee) Image(name string, img image.Image) error {
+	for _, s := range t {
+		if !WantsImages(s) { continue }
+		if err := s.Image(name, img); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t Tee) Data(name string, v interface{}) error {
+	for _, s := range t {
+		if !s.Enabled() { continue }
+		if err := s.Data(name, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writes <Dir>/<name>.png and <Dir>/<name>.json
+type DirSink struct {
+	Dir string
+}
+
+func NewDirSink(dir string) (DirSink, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return DirSink{}, fmt.Errorf("[NewDirSink] could not make %s: %w", dir, err)
+	}
+	return DirSink{dir}, nil
+}
+
+func (d DirSink) Enabled() bool { return true }
+
+func (d DirSink) Image(name string, img image.Image) error {
+	return imaging.SaveImage(img, filepath.Join(d.Dir, name + ".png"))
+}
+
+func (d DirSink) Data(name string, v interface{}) error {
+	buf, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		return fmt.Errorf("[DirSink.Data] could not encode %s: %w", name, err)
+	}
+	return os.WriteFile(filepath.Join(d.Dir, name + ".json"), buf, 0644)
+}
+
+// keeps everything in memory, mostly for tests. safe for concurrent use.
+type Memory struct {
+	mu sync.Mutex
+	Names []string	// in the order they arrived
+	Images map[string]image.Image
+	Values map[string]interface{}
+}
+
+func NewMemory() *Memory {
+	return &Memory{Images: make(map[string]image.Image), Values: make(map[string]interface{})}
+}
+
+func (m *Memory) Enabled() bool { return true }
+
+func (m *Memory) Image(name string, img image.Image) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Names = append(m.Names, name)
+	m.Images[name] = img
+	return nil
+}
+
+func (m *Memory) Data(name string, v interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Names = append(m.Names, name)
+	m.Values[name] = v
+	return nil
+}
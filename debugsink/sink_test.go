@@ -0,0 +1,73 @@
+package debugsink
+
+import (
+	"encoding/json"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDirSink(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "debug")
+	d, err := NewDirSink(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = d.Image("overlay", image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	if err = d.Data("potentials", []float64{1, 2.5}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "overlay.png")); err != nil {
+		t.Error(err)
+	}
+	buf, err := os.ReadFile(filepath.Join(dir, "potentials.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []float64
+	if err = json.Unmarshal(buf, &got); err != nil || len(got) != 2 || got[1] != 2.5 {
+		t.Errorf("potentials.json = %s (%v)", buf, err)
+	}
+}
+
+func TestOrNop(t *testing.T) {
+	if OrNop(nil).Enabled() {
+		t.Errorf("a nil sink should be disabled")
+	}
+	m := NewMemory()
+	if OrNop(m) != Sink(m) {
+		t.Errorf("OrNop should pass real sinks through")
+	}
+}
+
+// a sink that only keeps Data
+type dataOnly struct{ *Memory }
+
+func (dataOnly) WantsImages() bool { return false }
+
+func TestWantsImages(t *testing.T) {
+	if WantsImages(Nop{}) {
+		t.Errorf("a disabled sink shouldn't want images")
+	}
+	if !WantsImages(NewMemory()) {
+		t.Errorf("sinks want images unless they say otherwise")
+	}
+	d := dataOnly{NewMemory()}
+	if WantsImages(d) || WantsImages(Tee{d, Nop{}}) {
+		t.Errorf("nothing in the tee wants images")
+	}
+	m := NewMemory()
+	tee := Tee{d, m}
+	if !WantsImages(tee) {
+		t.Errorf("the Memory in the tee wants images")
+	}
+	if err := tee.Image("overlay", image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Images) != 0 || len(m.Images) != 1 {
+		t.Errorf("images went to %d data-only and %d memory sinks", len(d.Images), len(m.Images))
+	}
+}
@@ -0,0 +1,183 @@
+// Package drawing renders overlays onto images: anti-aliased lines of any
+// thickness, filled circles, polygons and bitmap text. everything is
+// composited over what is already there rather than replacing it, so
+// overlays keep the board visible underneath.
+package drawing
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/twolfe18/sudoku/geometry"
+)
+
+// composites c over the pixel at (x, y) with coverage alpha in [0,1]
+func Blend(img draw.Image, x, y int, c color.Color, alpha float64) {
+	if alpha <= 0.0 || !image.Pt(x, y).In(img.Bounds()) {
+		return
+	}
+	if alpha > 1.0 { alpha = 1.0 }
+	sr, sg, sb, sa := c.RGBA()	// premultiplied
+	dr, dg, db, da := img.At(x, y).RGBA()
+	keep := 1.0 - alpha * float64(sa) / 0xffff
+	mix := func(s, d uint32) uint16 {
+		return uint16(math.Min(0xffff, float64(s) * alpha + float64(d) * keep + 0.5))
+	}
+	img.Set(x, y, color.RGBA64{mix(sr, dr), mix(sg, dg), mix(sb, db), mix(sa, da)})
+}
+
+func fpart(x float64) float64 { return x - math.Floor(x) }
+func rfpart(x float64) float64 { return 1.0 - fpart(x) }
+
+// a one pixel wide line with Xiaolin Wu's algorithm.
+// integer coordinates are pixel centers, like everywhere else.
+func wu(img draw.Image, a, b geometry.Float64Point, c color.Color) {
+	x0, y0, x1, y1 := a.X, a.Y, b.X, b.Y
+	steep := math.Abs(y1 - y0) > math.Abs(x1 - x0)
+	if steep {
+		x0, y0, x1, y1 = y0, x0, y1, x1
+	}
+	if x0 > x1 {
+		x0, y0, x1, y1 = x1, y1, x0, y0
+	}
+	plot := func(x, y int, cov float64) {
+		if steep { x, y = y, x }
+		Blend(img, x, y, c, cov)
+	}
+	dx := x1 - x0
+	gradient := 1.0
+	if dx > 0.0 { gradient = (y1 - y0) / dx }
+
+	// first endpoint
+	xend := math.Round(x0)
+	yend := y0 + gradient * (xend - x0)
+	xgap := rfpart(x0 + 0.5)
+	xpx1 := int(xend)
+	ypx1 := int(math.Floor(yend))
+	plot(xpx1, ypx1, rfpart(yend) * xgap)
+	plot(xpx1, ypx1 + 1, fpart(yend) * xgap)
+	intery := yend + gradient
+
+	// second endpoint
+	xend = math.Round(x1)
+	yend = y1 + gradient * (xend - x1)
+	xgap = fpart(x1 + 0.5)
+	xpx2 := int(xend)
+	ypx2 := int(math.Floor(yend))
+	if xpx2 == xpx1 {
+		return	// shorter than a pixel, the first endpoint covers it
+	}
+	plot(xpx2, ypx2, rfpart(yend) * xgap)
+	plot(xpx2, ypx2 + 1, fpart(yend) * xgap)
+
+	for x := xpx1 + 1; x < xpx2; x++ {
+		y := int(math.Floor(intery))
+		plot(x, y, rfpart(intery))
+		plot(x, y + 1, fpart(intery))
+		intery += gradient
+	}
+}
+
+// a segment from a to b, thickness pixels across. thin segments use Wu's
+// algorithm, thicker ones are capsules with a one pixel soft edge.
+func Segment(img draw.Image, a, b geometry.Float64Point, thickness float64, c color.Color) {
+	if thickness <= 1.0 {
+		wu(img, a, b, c)
+		return
+	}
+	half := thickness / 2.0
+	l := geometry.Line{Left: a, Right: b}
+	box := image.Rect(
+		int(math.Floor(math.Min(a.X, b.X) - half - 1)), int(math.Floor(math.Min(a.Y, b.Y) - half - 1)),
+		int(math.Ceil(math.Max(a.X, b.X) + half + 1)) + 1, int(math.Ceil(math.Max(a.Y, b.Y) + half + 1)) + 1,
+	).Intersect(img.Bounds())
+	for y := box.Min.Y; y < box.Max.Y; y++ {
+		for x := box.Min.X; x < box.Max.X; x++ {
+			d := l.SegmentDistance(geometry.Float64Point{X: float64(x), Y: float64(y)})
+			Blend(img, x, y, c, half + 0.5 - d)
+		}
+	}
+}
+
+func Line(img draw.Image, l geometry.Line, thickness float64, c color.Color) {
+	Segment(img, l.Left, l.Right, thickness, c)
+}
+
+// a bent line, thickness pixels across. thick curves are blended once per
+// pixel so the joints between segments don't show.
+func Curve(img draw.Image, cv geometry.Curve, thickness float64, c color.Color) {
+	segs := cv.Segments()
+	if thickness <= 1.0 {
+		for _, s := range segs {
+			wu(img, s.Left, s.Right, c)
+		}
+		return
+	}
+	half := thickness / 2.0
+	box := image.Rectangle{}
+	for _, s := range segs {
+		box = box.Union(image.Rect(
+			int(math.Floor(math.Min(s.Left.X, s.Right.X) - half - 1)), int(math.Floor(math.Min(s.Left.Y, s.Right.Y) - half - 1)),
+			int(math.Ceil(math.Max(s.Left.X, s.Right.X) + half + 1)) + 1, int(math.Ceil(math.Max(s.Left.Y, s.Right.Y) + half + 1)) + 1,
+		))
+	}
+	box = box.Intersect(img.Bounds())
+	for y := box.Min.Y; y < box.Max.Y; y++ {
+		for x := box.Min.X; x < box.Max.X; x++ {
+			d := cv.Distance(geometry.Float64Point{X: float64(x), Y: float64(y)})
+			Blend(img, x, y, c, half + 0.5 - d)
+		}
+	}
+}
+
+// a filled disc of radius r with a one pixel soft edge, e.g. for corners
+func Circle(img draw.Image, center geometry.Float64Point, r float64, c color.Color) {
+	box := image.Rect(
+		int(math.Floor(center.X - r - 1)), int(math.Floor(center.Y - r - 1)),
+		int(math.Ceil(center.X + r + 1)) + 1, int(math.Ceil(center.Y + r + 1)) + 1,
+	).Intersect(img.Bounds())
+	for y := box.Min.Y; y < box.Max.Y; y++ {
+		for x := box.Min.X; x < box.Max.X; x++ {
+			d := math.Hypot(float64(x) - center.X, float64(y) - center.Y)
+			Blend(img, x, y, c, r + 0.5 - d)
+		}
+	}
+}
+
+// the outline of a closed polygon
+func Polygon(img draw.Image, poly geometry.Polygon, thickness float64, c color.Color) {
+	for i, p := range poly {
+		Segment(img, p, poly[(i + 1) % len(poly)], thickness, c)
+	}
+}
+
+// fills poly, each pixel covered in proportion to how many of a 4x4 grid
+// of samples inside it fall in the polygon
+func FillPolygon(img draw.Image, poly geometry.Polygon, c color.Color) {
+	if len(poly) < 3 {
+		return
+	}
+	lo, hi := poly[0], poly[0]
+	for _, p := range poly {
+		lo.X, lo.Y = math.Min(lo.X, p.X), math.Min(lo.Y, p.Y)
+		hi.X, hi.Y = math.Max(hi.X, p.X), math.Max(hi.Y, p.Y)
+	}
+	box := image.Rect(int(math.Floor(lo.X)), int(math.Floor(lo.Y)), int(math.Ceil(hi.X)) + 1, int(math.Ceil(hi.Y)) + 1).Intersect(img.Bounds())
+	const n = 4
+	for y := box.Min.Y; y < box.Max.Y; y++ {
+		for x := box.Min.X; x < box.Max.X; x++ {
+			inside := 0
+			for i := 0; i < n; i++ {
+				for j := 0; j < n; j++ {
+					// samples spread over the pixel centered at (x, y)
+					sx := float64(x) - 0.5 + (float64(i) + 0.5) / n
+					sy := float64(y) - 0.5 + (float64(j) + 0.5) / n
+					if poly.Contains(geometry.Float64Point{X: sx, Y: sy}) { inside++ }
+				}
+			}
+			Blend(img, x, y, c, float64(inside) / (n * n))
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package drawing
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/twolfe18/sudoku/geometry"
+)
+
+func white(size int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for i := range img.Pix { img.Pix[i] = 255 }
+	return img
+}
+
+func pt(x, y float64) geometry.Float64Point { return geometry.Float64Point{X: x, Y: y} }
+
+// how much darker than white the red channel got, summed over the image
+func ink(img *image.RGBA) (total float64) {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			total += float64(255 - img.RGBAAt(x, y).R) / 255.0
+		}
+	}
+	return total
+}
+
+func TestBlendComposites(t *testing.T) {
+	img := white(4)
+	Blend(img, 1, 1, color.RGBA{0, 0, 0, 255}, 0.5)
+	if r := img.RGBAAt(1, 1).R; r < 126 || r > 129 {
+		t.Errorf("half black over white should be mid gray, got %d", r)
+	}
+	// drawing the same thing again keeps darkening instead of overwriting
+	Blend(img, 1, 1, color.RGBA{0, 0, 0, 255}, 0.5)
+	if r := img.RGBAAt(1, 1).R; r < 62 || r > 66 {
+		t.Errorf("second blend should composite, got %d", r)
+	}
+	Blend(img, 10, 10, color.Black, 1.0)	// out of bounds is ignored
+	Blend(img, 2, 2, color.Black, 0.0)
+	if img.RGBAAt(2, 2).R != 255 {
+		t.Errorf("zero coverage changed the pixel")
+	}
+}
+
+func TestWuCoverage(t *testing.T) {
+	// each column a thin line crosses should get about one pixel of ink
+	for _, l := range [][2]geometry.Float64Point{
+		{pt(2, 2), pt(28, 9)}, {pt(3, 27), pt(25, 5.5)}, {pt(5, 2), pt(9, 28)}, {pt(2, 10.5), pt(28, 10.5)},
+	} {
+		img := white(32)
+		Segment(img, l[0], l[1], 1.0, color.Black)
+		major := math.Max(math.Abs(l[1].X - l[0].X), math.Abs(l[1].Y - l[0].Y)) + 1
+		if got := ink(img); math.Abs(got - major) > 1.5 {
+			t.Errorf("%v: %.2f pixels of ink, expected about %.0f", l, got, major)
+		}
+	}
+}
+
+func TestThickSegment(t *testing.T) {
+	img := white(64)
+	Segment(img, pt(10, 32), pt(50, 32), 5.0, color.Black)
+	// a capsule: 40x5 body plus two half discs
+	want := 40.0 * 5.0 + math.Pi * 2.5 * 2.5
+	if got := ink(img); math.Abs(got - want) > 0.05 * want {
+		t.Errorf("%.1f pixels of ink, expected about %.1f", got, want)
+	}
+	if img.RGBAAt(30, 32).R != 0 || img.RGBAAt(30, 38).R != 255 {
+		t.Errorf("thick line should be solid in the middle and clean outside")
+	}
+}
+
+func TestCurve(t *testing.T) {
+	// a straight curve inks the same as the segment
+	l := geometry.Line{Left: pt(10, 32), Right: pt(50, 32)}
+	img := white(64)
+	Curve(img, geometry.StraightCurve(l), 5.0, color.Black)
+	want := 40.0 * 5.0 + math.Pi * 2.5 * 2.5
+	if got := ink(img); math.Abs(got - want) > 0.05 * want {
+		t.Errorf("%.1f pixels of ink, expected about %.1f", got, want)
+	}
+
+	// bent, the middle moves and the joints don't pile up extra ink
+	c := geometry.StraightCurve(l)
+	c.SetBend(10.0)
+	img = white(64)
+	Curve(img, c, 5.0, color.Black)
+	m := c.At(0.5)
+	if img.RGBAAt(int(math.Round(m.X)), int(math.Round(m.Y))).R != 0 || img.RGBAAt(30, 32).R != 255 {
+		t.Errorf("the curve should be drawn through %s, not along its chord", m)
+	}
+}
+
+func TestCircle(t *testing.T) {
+	img := white(32)
+	Circle(img, pt(16, 16), 6.0, color.Black)
+	want := math.Pi * 36.0
+	if got := ink(img); math.Abs(got - want) > 0.05 * want {
+		t.Errorf("%.1f pixels of ink, expected about %.1f", got, want)
+	}
+}
+
+func TestFillPolygon(t *testing.T) {
+	img := white(32)
+	tri := geometry.Polygon{pt(4, 4), pt(28, 4), pt(4, 28)}
+	FillPolygon(img, tri, color.Black)
+	if got, want := ink(img), tri.Area(); math.Abs(got - want) > 0.05 * want {
+		t.Errorf("%.1f pixels of ink, expected about %.1f", got, want)
+	}
+	Polygon(img, tri, 1.0, color.RGBA{255, 0, 0, 255})	// just shouldn't panic
+}
+
+func TestFontsAreRectangular(t *testing.T) {
+	for name, f := range Fonts {
+		for c, g := range f.Glyphs {
+			if len(g) != f.Height {
+				t.Errorf("%s %q has %d rows", name, c, len(g))
+			}
+			for _, row := range g {
+				if len(row) != f.Width {
+					t.Errorf("%s %q has a row %q", name, c, row)
+				}
+			}
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	img := white(96)
+	box := Font5x7.Text(img, "Iter 7", 1, 1, 2, color.Black)
+	if box != Font5x7.Measure("ITER 7", 1, 1, 2) || box.Dx() != (6 * 6 - 1) * 2 || box.Dy() != 14 {
+		t.Errorf("unexpected box %v", box)
+	}
+	// the top bar of the T in ITER, lower case is drawn as upper case
+	if img.RGBAAt(1 + 6 * 2, 1).R != 0 {
+		t.Errorf("expected ink at the top of the T")
+	}
+	if ink(img) == 0 || img.RGBAAt(box.Max.X + 1, 1).R != 255 {
+		t.Errorf("text drew outside its box or not at all")
+	}
+}
@@ -0,0 +1,150 @@
+package drawing
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"unicode"
+)
+
+// tiny bitmap fonts so we can render digits and labels without any font
+// files. each glyph is a list of rows, '#' is ink.
+type BitmapFont struct {
+	Width, Height int
+	Glyphs map[rune][]string
+}
+
+var Font5x7 = &BitmapFont{5, 7, map[rune][]string{
+	'0': {" ### ", "#   #", "#  ##", "# # #", "##  #", "#   #", " ### "},
+	'1': {"  #  ", " ##  ", "  #  ", "  #  ", "  #  ", "  #  ", " ### "},
+	'2': {" ### ", "#   #", "    #", "   # ", "  #  ", " #   ", "#####"},
+	'3': {"#####", "   # ", "  #  ", "   # ", "    #", "#   #", " ### "},
+	'4': {"   # ", "  ## ", " # # ", "#  # ", "#####", "   # ", "   # "},
+	'5': {"#####", "#    ", "#### ", "    #", "    #", "#   #", " ### "},
+	'6': {"  ## ", " #   ", "#    ", "#### ", "#   #", "#   #", " ### "},
+	'7': {"#####", "    #", "   # ", "  #  ", " #   ", " #   ", " #   "},
+	'8': {" ### ", "#   #", "#   #", " ### ", "#   #", "#   #", " ### "},
+	'9': {" ### ", "#   #", "#   #", " ####", "    #", "   # ", " ##  "},
+
+	'A': {" ### ", "#   #", "#   #", "#####", "#   #", "#   #", "#   #"},
+	'B': {"#### ", "#   #", "#   #", "#### ", "#   #", "#   #", "#### "},
+	'C': {" ### ", "#   #", "#    ", "#    ", "#    ", "#   #", " ### "},
+	'D': {"#### ", "#   #", "#   #", "#   #", "#   #", "#   #", "#### "},
+	'E': {"#####", "#    ", "#    ", "#### ", "#    ", "#    ", "#####"},
+	'F': {"#####", "#    ", "#    ", "#### ", "#    ", "#    ", "#    "},
+	'G': {" ### ", "#   #", "#    ", "# ###", "#   #", "#   #", " ####"},
+	'H': {"#   #", "#   #", "#   #", "#####", "#   #", "#   #", "#   #"},
+	'I': {" ### ", "  #  ", "  #  ", "  #  ", "  #  ", "  #  ", " ### "},
+	'J': {"  ###", "   # ", "   # ", "   # ", "   # ", "#  # ", " ##  "},
+	'K': {"#   #", "#  # ", "# #  ", "##   ", "# #  ", "#  # ", "#   #"},
+	'L': {"#    ", "#    ", "#    ", "#    ", "#    ", "#    ", "#####"},
+	'M': {"#   #", "## ##", "# # #", "# # #", "#   #", "#   #", "#   #"},
+	'N': {"#   #", "#   #", "##  #", "# # #", "#  ##", "#   #", "#   #"},
+	'O': {" ### ", "#   #", "#   #", "#   #", "#   #", "#   #", " ### "},
+	'P': {"#### ", "#   #", "#   #", "#### ", "#    ", "#    ", "#    "},
+	'Q': {" ### ", "#   #", "#   #", "#   #", "# # #", "#  # ", " ## #"},
+	'R': {"#### ", "#   #", "#   #", "#### ", "# #  ", "#  # ", "#   #"},
+	'S': {" ####", "#    ", "#    ", " ### ", "    #", "    #", "#### "},
+	'T': {"#####", "  #  ", "  #  ", "  #  ", "  #  ", "  #  ", "  #  "},
+	'U': {"#   #", "#   #", "#   #", "#   #", "#   #", "#   #", " ### "},
+	'V': {"#   #", "#   #", "#   #", "#   #", "#   #", " # # ", "  #  "},
+	'W': {"#   #", "#   #", "#   #", "# # #", "# # #", "# # #", " # # "},
+	'X': {"#   #", "#   #", " # # ", "  #  ", " # # ", "#   #", "#   #"},
+	'Y': {"#   #", "#   #", " # # ", "  #  ", "  #  ", "  #  ", "  #  "},
+	'Z': {"#####", "    #", "   # ", "  #  ", " #   ", "#    ", "#####"},
+
+	'.': {"     ", "     ", "     ", "     ", "     ", " ##  ", " ##  "},
+	',': {"     ", "     ", "     ", "     ", " ##  ", "  #  ", " #   "},
+	'-': {"     ", "     ", "     ", "#####", "     ", "     ", "     "},
+	'+': {"     ", "  #  ", "  #  ", "#####", "  #  ", "  #  ", "     "},
+	'=': {"     ", "     ", "#####", "     ", "#####", "     ", "     "},
+	':': {"     ", " ##  ", " ##  ", "     ", " ##  ", " ##  ", "     "},
+	'/': {"     ", "    #", "   # ", "  #  ", " #   ", "#    ", "     "},
+	'%': {"##   ", "##  #", "   # ", "  #  ", " #   ", "#  ##", "   ##"},
+	'(': {"   # ", "  #  ", " #   ", " #   ", " #   ", "  #  ", "   # "},
+	')': {" #   ", "  #  ", "   # ", "   # ", "   # ", "  #  ", " #   "},
+	'#': {" # # ", " # # ", "#####", " # # ", "#####", " # # ", " # # "},
+	' ': {"     ", "     ", "     ", "     ", "     ", "     ", "     "},
+}}
+
+var Font3x5 = &BitmapFont{3, 5, map[rune][]string{
+	'0': {"###", "# #", "# #", "# #", "###"},
+	'1': {" # ", "## ", " # ", " # ", "###"},
+	'2': {"###", "  #", "###", "#  ", "###"},
+	'3': {"###", "  #", "###", "  #", "###"},
+	'4': {"# #", "# #", "###", "  #", "  #"},
+	'5': {"###", "#  ", "###", "  #", "###"},
+	'6': {"###", "#  ", "###", "# #", "###"},
+	'7': {"###", "  #", "  #", "  #", "  #"},
+	'8': {"###", "# #", "###", "# #", "###"},
+	'9': {"###", "# #", "###", "  #", "###"},
+	'.': {"   ", "   ", "   ", "   ", " # "},
+	'-': {"   ", "   ", "###", "   ", "   "},
+	' ': {"   ", "   ", "   ", "   ", "   "},
+}}
+
+var Fonts = map[string]*BitmapFont{
+	"5x7": Font5x7,
+	"3x5": Font3x5,
+}
+
+// the glyph for c, falling back to upper case since the fonts only have that
+func (f *BitmapFont) glyph(c rune) ([]string, bool) {
+	g, ok := f.Glyphs[c]
+	if !ok {
+		g, ok = f.Glyphs[unicode.ToUpper(c)]
+	}
+	return g, ok
+}
+
+// whether glyph c has ink at (u, v), both in [0,1) across the glyph box
+func (f *BitmapFont) Ink(c rune, u, v float64) bool {
+	g, ok := f.glyph(c)
+	if !ok || u < 0.0 || v < 0.0 || u >= 1.0 || v >= 1.0 {
+		return false
+	}
+	return g[int(v * float64(f.Height))][int(u * float64(f.Width))] == '#'
+}
+
+// the box s takes up when drawn at scale with its top left corner at (x, y)
+func (f *BitmapFont) Measure(s string, x, y, scale int) image.Rectangle {
+	n := 0
+	for _ = range s {
+		n++
+	}
+	w := 0
+	if n > 0 {
+		w = (n * (f.Width + 1) - 1) * scale
+	}
+	return image.Rect(x, y, x + w, y + f.Height * scale)
+}
+
+// writes s with its top left corner at (x, y), each font pixel a scale x scale
+// block. glyphs the font doesn't have are left blank. returns the box drawn in.
+func (f *BitmapFont) Text(img draw.Image, s string, x, y, scale int, c color.Color) image.Rectangle {
+	box := f.Measure(s, x, y, scale)
+	for _, ch := range s {
+		if g, ok := f.glyph(ch); ok {
+			for v, row := range g {
+				for u, ink := range row {
+					if ink != '#' { continue }
+					block := image.Rect(x + u * scale, y + v * scale, x + (u + 1) * scale, y + (v + 1) * scale)
+					draw.Draw(img, block, image.NewUniform(c), image.Point{}, draw.Over)
+				}
+			}
+		}
+		x += (f.Width + 1) * scale
+	}
+	return box
+}
+
+// Text on top of a filled box with a pad-pixel margin, so labels stay
+// readable over a busy image. returns the box including the margin.
+func (f *BitmapFont) Label(img draw.Image, s string, x, y, scale, pad int, fg, bg color.Color) image.Rectangle {
+	box := f.Measure(s, x + pad, y + pad, scale)
+	box.Min = box.Min.Sub(image.Pt(pad, pad))
+	box.Max = box.Max.Add(image.Pt(pad, pad))
+	draw.Draw(img, box, image.NewUniform(bg), image.Point{}, draw.Over)
+	f.Text(img, s, x + pad, y + pad, scale, fg)
+	return box
+}
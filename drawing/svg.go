@@ -0,0 +1,170 @@
+package drawing
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/twolfe18/sudoku/geometry"
+)
+
+// a vector overlay on top of an image. unlike the raster functions nothing
+// is rounded, line endpoints keep full precision. as everywhere else,
+// integer coordinates are pixel centers.
+type SVG struct {
+	Width, Height int
+
+	// the background: Image is embedded as a PNG, otherwise ImageHref
+	// (a path or URL) is linked. both empty means no background.
+	Image image.Image
+	ImageHref string
+
+	Lines []SVGLine
+	Curves []SVGCurve
+	Points []SVGPoint
+}
+
+type SVGLine struct {
+	Line geometry.Line
+	Width float64
+	Color color.RGBA
+	Label string	// drawn at the midpoint, skipped if empty
+}
+
+type SVGCurve struct {
+	Curve geometry.Curve
+	Width float64
+	Color color.RGBA
+	Label string	// drawn at the middle of the curve, skipped if empty
+}
+
+type SVGPoint struct {
+	P geometry.Float64Point
+	Radius float64
+	Color color.RGBA
+	Label string
+}
+
+func NewSVG(bounds image.Rectangle) *SVG {
+	return &SVG{Width: bounds.Dx(), Height: bounds.Dy()}
+}
+
+func (s *SVG) AddLine(l geometry.Line, width float64, c color.RGBA, label string) {
+	s.Lines = append(s.Lines, SVGLine{l, width, c, label})
+}
+
+func (s *SVG) AddCurve(cv geometry.Curve, width float64, c color.RGBA, label string) {
+	s.Curves = append(s.Curves, SVGCurve{cv, width, c, label})
+}
+
+func (s *SVG) AddPoint(p geometry.Float64Point, r float64, c color.RGBA, label string) {
+	s.Points = append(s.Points, SVGPoint{p, r, c, label})
+}
+
+// shortest exact representation, so a round trip through the file is lossless
+func num(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func opacity(c color.RGBA) string {
+	return num(float64(c.A) / 255.0)
+}
+
+func escape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func (s *SVG) href() (string, error) {
+	if s.Image == nil {
+		return s.ImageHref, nil
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, s.Image); err != nil {
+		return "", fmt.Errorf("[SVG.Encode] could not embed image: %w", err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+func (s *SVG) Encode(w io.Writer) error {
+	href, err := s.href()
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" xmlns:xlink=\"http://www.w3.org/1999/xlink\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n",
+		s.Width, s.Height, s.Width, s.Height)
+	if href != "" {
+		fmt.Fprintf(&b, "<image x=\"0\" y=\"0\" width=\"%d\" height=\"%d\" xlink:href=\"%s\" href=\"%s\" style=\"image-rendering:pixelated\"/>\n",
+			s.Width, s.Height, escape(href), escape(href))
+	}
+
+	// pixel (x, y) covers [x, x+1) in the image, so centers are half a pixel in
+	fmt.Fprintf(&b, "<g transform=\"translate(0.5 0.5)\" font-family=\"monospace\" font-size=\"8\">\n")
+	for i, l := range s.Lines {
+		fmt.Fprintf(&b, "<path id=\"line%d\" d=\"M %s %s L %s %s\" stroke=\"%s\" stroke-opacity=\"%s\" stroke-width=\"%s\" fill=\"none\"/>\n",
+			i, num(l.Line.Left.X), num(l.Line.Left.Y), num(l.Line.Right.X), num(l.Line.Right.Y),
+			hex(l.Color), opacity(l.Color), num(l.Width))
+	}
+	for i, cv := range s.Curves {
+		c := cv.Curve
+		fmt.Fprintf(&b, "<path id=\"curve%d\" d=\"M %s %s Q %s %s %s %s\" stroke=\"%s\" stroke-opacity=\"%s\" stroke-width=\"%s\" fill=\"none\"/>\n",
+			i, num(c.Left.X), num(c.Left.Y), num(c.Control.X), num(c.Control.Y), num(c.Right.X), num(c.Right.Y),
+			hex(cv.Color), opacity(cv.Color), num(cv.Width))
+	}
+	for _, p := range s.Points {
+		fmt.Fprintf(&b, "<circle cx=\"%s\" cy=\"%s\" r=\"%s\" fill=\"%s\" fill-opacity=\"%s\"/>\n",
+			num(p.P.X), num(p.P.Y), num(p.Radius), hex(p.Color), opacity(p.Color))
+	}
+
+	// labels last so lines don't cover them
+	for _, l := range s.Lines {
+		if l.Label == "" { continue }
+		m := l.Line.Midpoint()
+		fmt.Fprintf(&b, "<text x=\"%s\" y=\"%s\" fill=\"%s\" stroke=\"white\" stroke-width=\"2\" paint-order=\"stroke\">%s</text>\n",
+			num(m.X + 2), num(m.Y - 2), hex(l.Color), escape(l.Label))
+	}
+	for _, cv := range s.Curves {
+		if cv.Label == "" { continue }
+		m := cv.Curve.At(0.5)
+		fmt.Fprintf(&b, "<text x=\"%s\" y=\"%s\" fill=\"%s\" stroke=\"white\" stroke-width=\"2\" paint-order=\"stroke\">%s</text>\n",
+			num(m.X + 2), num(m.Y - 2), hex(cv.Color), escape(cv.Label))
+	}
+	for _, p := range s.Points {
+		if p.Label == "" { continue }
+		fmt.Fprintf(&b, "<text x=\"%s\" y=\"%s\" fill=\"%s\" stroke=\"white\" stroke-width=\"2\" paint-order=\"stroke\">%s</text>\n",
+			num(p.P.X + p.Radius + 1), num(p.P.Y - p.Radius - 1), hex(p.Color), escape(p.Label))
+	}
+	b.WriteString("</g>\n</svg>\n")
+	_, err = w.Write(b.Bytes())
+	return err
+}
+
+// writes the svg to outf, replacing anything already there
+func (s *SVG) Save(outf string) (err error) {
+	writer, err := os.Create(outf)
+	if err != nil {
+		return fmt.Errorf("[SVG.Save] could not open %s: %w", outf, err)
+	}
+	defer func() {
+		if cerr := writer.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("[SVG.Save] could not close %s: %w", outf, cerr)
+		}
+	}()
+	if err = s.Encode(writer); err != nil {
+		return fmt.Errorf("[SVG.Save] problem saving to %s: %w", outf, err)
+	}
+	return nil
+}
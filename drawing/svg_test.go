@@ -0,0 +1,117 @@
+package drawing
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/twolfe18/sudoku/geometry"
+)
+
+// just the parts of the svg the tests look at
+type svgDoc struct {
+	Image struct {
+		Href string `xml:"href,attr"`
+	} `xml:"image"`
+	Group struct {
+		Paths []struct {
+			D string `xml:"d,attr"`
+		} `xml:"path"`
+		Circles []struct {
+			CX string `xml:"cx,attr"`
+		} `xml:"circle"`
+		Texts []string `xml:"text"`
+	} `xml:"g"`
+}
+
+func parseSVG(t *testing.T, s *SVG) (doc svgDoc) {
+	var buf bytes.Buffer
+	if err := s.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("bad svg: %s\n%s", err, buf.String())
+	}
+	return doc
+}
+
+func TestSVGPrecision(t *testing.T) {
+	s := NewSVG(image.Rect(0, 0, 32, 32))
+	l := geometry.Line{Left: pt(1.0/3.0, 2.125), Right: pt(30.000000001, 29.5)}
+	s.AddLine(l, 1.0, color.RGBA{255, 0, 0, 255}, "0: 1.5 <a&b>")
+	s.AddPoint(pt(4.25, 5), 2.0, color.RGBA{0, 255, 0, 255}, "")
+	doc := parseSVG(t, s)
+
+	if len(doc.Group.Paths) != 1 || len(doc.Group.Circles) != 1 {
+		t.Fatalf("expected a path and a circle, got %+v", doc.Group)
+	}
+	var x0, y0, x1, y1 float64
+	if _, err := fmt.Sscanf(doc.Group.Paths[0].D, "M %g %g L %g %g", &x0, &y0, &x1, &y1); err != nil {
+		t.Fatal(err)
+	}
+	if x0 != l.Left.X || y0 != l.Left.Y || x1 != l.Right.X || y1 != l.Right.Y {
+		t.Errorf("endpoints lost precision: %s", doc.Group.Paths[0].D)
+	}
+	if len(doc.Group.Texts) != 1 || doc.Group.Texts[0] != "0: 1.5 <a&b>" {
+		t.Errorf("labels: %q", doc.Group.Texts)
+	}
+	if doc.Image.Href != "" {
+		t.Errorf("no background was given, got %q", doc.Image.Href)
+	}
+}
+
+func TestSVGBackground(t *testing.T) {
+	img := white(8)
+	img.Set(3, 4, color.Black)
+	s := NewSVG(img.Bounds())
+	s.Image = img
+	href := parseSVG(t, s).Image.Href
+	const prefix = "data:image/png;base64,"
+	if !strings.HasPrefix(href, prefix) {
+		t.Fatalf("expected an embedded png, got %.40q", href)
+	}
+	raw, err := base64.StdEncoding.DecodeString(href[len(prefix):])
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := png.Decode(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, _, _, _ := back.At(3, 4).RGBA(); r != 0 {
+		t.Errorf("embedded image doesn't match")
+	}
+
+	s = NewSVG(img.Bounds())
+	s.ImageHref = "boards/a&b.png"
+	if href := parseSVG(t, s).Image.Href; href != "boards/a&b.png" {
+		t.Errorf("linked href came back as %q", href)
+	}
+}
+
+func TestSVGCurve(t *testing.T) {
+	s := NewSVG(image.Rect(0, 0, 32, 32))
+	c := geometry.Curve{Left: pt(1, 2), Control: pt(16.25, 9), Right: pt(30, 2.5)}
+	s.AddCurve(c, 1.0, color.RGBA{255, 0, 0, 255}, "bent")
+	doc := parseSVG(t, s)
+	if len(doc.Group.Paths) != 1 {
+		t.Fatalf("expected one path, got %+v", doc.Group)
+	}
+	var got geometry.Curve
+	if _, err := fmt.Sscanf(doc.Group.Paths[0].D, "M %g %g Q %g %g %g %g", &got.Left.X, &got.Left.Y,
+		&got.Control.X, &got.Control.Y, &got.Right.X, &got.Right.Y); err != nil {
+		t.Fatal(err)
+	}
+	if got != c {
+		t.Errorf("curve came back as %s", got)
+	}
+	if len(doc.Group.Texts) != 1 || doc.Group.Texts[0] != "bent" {
+		t.Errorf("labels: %q", doc.Group.Texts)
+	}
+}
@@ -0,0 +1,105 @@
+package evaluation
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/twolfe18/sudoku/alignment"
+)
+
+// a term of the EdgeDetector potential that can be switched off, to
+// measure what it is worth
+type ablation struct {
+	// whether the term is on at all, ablating a term that is already off
+	// would just compare p to itself
+	active func(p alignment.EdgeDetectorParams) bool
+	off func(p *alignment.EdgeDetectorParams)
+}
+
+var ablations = map[string]ablation{
+	"cross": {
+		func(p alignment.EdgeDetectorParams) bool { return p.CrossWeight > 0.0 },
+		func(p *alignment.EdgeDetectorParams) { p.CrossWeight = 0.0 },
+	},
+	"parallel": {
+		func(p alignment.EdgeDetectorParams) bool { return p.ParallelWeight > 0.0 },
+		func(p *alignment.EdgeDetectorParams) { p.ParallelWeight = 0.0 },
+	},
+	"orthogonal": {
+		func(p alignment.EdgeDetectorParams) bool { return p.OrthogonalWeight > 0.0 },
+		func(p *alignment.EdgeDetectorParams) { p.OrthogonalWeight = 0.0 },
+	},
+	"spacing": {
+		func(p alignment.EdgeDetectorParams) bool { return p.SpacingWeight > 0.0 },
+		func(p *alignment.EdgeDetectorParams) { p.SpacingWeight = 0.0 },
+	},
+}
+
+func AblationNames() (names []string) {
+	for k := range ablations {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type AblationResult struct {
+	Term string
+	With, Without EvalReport
+}
+
+// parses "cross,..." against AblationNames
+func ParseAblations(spec string) (terms []string, err error) {
+	for _, t := range strings.Split(spec, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" { continue }
+		if _, ok := ablations[t]; !ok {
+			return nil, fmt.Errorf("[ParseAblations] unknown term %q, have %s", t, strings.Join(AblationNames(), ", "))
+		}
+		terms = append(terms, t)
+	}
+	return terms, nil
+}
+
+// aligns every image with p as given and again with each term turned off.
+// both runs use p.Seed, but a different setting soon takes a different
+// path through the random draws, so small differences are still noise.
+func Ablate(p alignment.EdgeDetectorParams, imgs []LabeledImage, terms []string) ([]AblationResult, error) {
+	with, err := EvaluateParams(p, imgs)
+	if err != nil {
+		return nil, err
+	}
+	var results []AblationResult
+	for _, t := range terms {
+		a, ok := ablations[t]
+		if !ok {
+			return nil, fmt.Errorf("[Ablate] unknown term %q", t)
+		}
+		if !a.active(p) {
+			return nil, fmt.Errorf("[Ablate] %s is already off in this config, turn it on to see what it is worth", t)
+		}
+		q := p
+		a.off(&q)
+		without, err := EvaluateParams(q, imgs)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, AblationResult{t, with, without})
+	}
+	return results, nil
+}
+
+func PrintAblations(w io.Writer, results []AblationResult) {
+	fmt.Fprintf(w, "%-12s %8s %10s %10s %10s %10s\n", "term", "", "mean(px)", "median(px)", "cell IoU", "matched")
+	row := func(name, which string, r EvalReport) {
+		fmt.Fprintf(w, "%-12s %8s %10.2f %10.2f %10.3f %9.1f%%\n", name, which, r.MeanCornerError, r.MedianCornerError, r.MeanCellIoU, 100.0 * r.CellMatchRate)
+	}
+	for _, r := range results {
+		row(r.Term, "with", r.With)
+		row("", "without", r.Without)
+		fmt.Fprintf(w, "%-12s %8s %+10.2f %10s %+10.3f %+9.1f%%\n", "", "gain", r.Without.MeanCornerError - r.With.MeanCornerError, "",
+			r.With.MeanCellIoU - r.Without.MeanCellIoU, 100.0 * (r.With.CellMatchRate - r.Without.CellMatchRate))
+	}
+}
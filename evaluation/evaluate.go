@@ -0,0 +1,199 @@
+package evaluation
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/twolfe18/sudoku/alignment"
+	"github.com/twolfe18/sudoku/geometry"
+	"github.com/twolfe18/sudoku/imaging"
+	"github.com/twolfe18/sudoku/logging"
+)
+
+var log = logging.For("evaluation")
+
+// compares fitted lattices to Annotations. corner error is the distance in
+// pixels between fitted and labeled board corners, cell IoU is the overlap
+// of each fitted cell with its labeled cell.
+
+// a cell with at least this IoU counts as found
+const CellMatchIoU = 0.5
+
+type LabeledImage struct {
+	Path string
+	Truth alignment.Annotation
+}
+
+// images OpenImage can read
+var ImageExtensions = []string{".png", ".jpg", ".jpeg", ".gif"}
+
+// every image in dir that has an annotation next to it
+func LoadDataset(dir string) (imgs []LabeledImage, err error) {
+	var paths []string
+	for _, ext := range ImageExtensions {
+		matches, err := filepath.Glob(filepath.Join(dir, "*" + ext))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, matches...)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		if _, err := os.Stat(alignment.AnnotationPath(p)); err != nil {
+			log.Info("skipping image with no annotation", "path", p)
+			continue
+		}
+		a, err := alignment.LoadAnnotation(alignment.AnnotationPath(p))
+		if err != nil {
+			return nil, err
+		}
+		imgs = append(imgs, LabeledImage{p, a})
+	}
+	if len(imgs) == 0 {
+		return nil, fmt.Errorf("[LoadDataset] no annotated images in %s", dir)
+	}
+	return imgs, nil
+}
+
+type AlignmentScore struct {
+	Path string
+	MeanCornerError, MaxCornerError float64	// pixels
+	MeanCellIoU float64
+	CellsMatched int	// cells with IoU >= CellMatchIoU
+	Seconds float64		// time spent aligning, if known
+}
+
+// a fitted lattice, straight (alignment.EdgeDetector) or bent
+// (alignment.CurvedGrid)
+type Fit interface {
+	Corners() [4]geometry.Float64Point
+	CellQuad(r, c int) geometry.Polygon
+}
+
+func ScoreAlignment(fit Fit, truth alignment.Annotation) (s AlignmentScore) {
+	corners := fit.Corners()
+	for i := range corners {
+		e := geometry.Distance(corners[i], truth.Corners[i])
+		s.MeanCornerError += e / 4.0
+		s.MaxCornerError = math.Max(s.MaxCornerError, e)
+	}
+	for r := 0; r < alignment.SudokuGridDimension; r++ {
+		for c := 0; c < alignment.SudokuGridDimension; c++ {
+			// Clip needs a convex clip polygon, the labeled cell is one but
+			// a bent cell's many sided outline may not be
+			iou := geometry.IoU(fit.CellQuad(r, c), truth.CellQuad(r, c))
+			s.MeanCellIoU += iou
+			if iou >= CellMatchIoU { s.CellsMatched++ }
+		}
+	}
+	s.MeanCellIoU /= float64(alignment.SudokuGridDimension * alignment.SudokuGridDimension)
+	return s
+}
+
+type EvalReport struct {
+	Scores []AlignmentScore
+	MeanCornerError, MedianCornerError, MaxCornerError float64
+	MeanCellIoU float64
+	CellMatchRate float64	// fraction of all cells with IoU >= CellMatchIoU
+	MeanSeconds float64
+}
+
+func Summarize(scores []AlignmentScore) (r EvalReport) {
+	r.Scores = scores
+	if len(scores) == 0 {
+		return r
+	}
+	errs := make([]float64, len(scores))
+	for i, s := range scores {
+		errs[i] = s.MeanCornerError
+		r.MeanCornerError += s.MeanCornerError
+		r.MaxCornerError = math.Max(r.MaxCornerError, s.MaxCornerError)
+		r.MeanCellIoU += s.MeanCellIoU
+		r.CellMatchRate += float64(s.CellsMatched)
+		r.MeanSeconds += s.Seconds
+	}
+	n := float64(len(scores))
+	r.MeanCornerError /= n
+	r.MeanCellIoU /= n
+	r.CellMatchRate /= n * float64(alignment.SudokuGridDimension * alignment.SudokuGridDimension)
+	r.MeanSeconds /= n
+	sort.Float64s(errs)
+	r.MedianCornerError = errs[len(errs)/2]
+	if len(errs) % 2 == 0 {
+		r.MedianCornerError = (errs[len(errs)/2 - 1] + errs[len(errs)/2]) / 2.0
+	}
+	return r
+}
+
+func (r EvalReport) Print(w io.Writer) {
+	fmt.Fprintf(w, "%-40s %10s %10s %10s %8s\n", "image", "mean(px)", "max(px)", "cell IoU", "matched")
+	for _, s := range r.Scores {
+		fmt.Fprintf(w, "%-40s %10.2f %10.2f %10.3f %5d/%d\n", s.Path, s.MeanCornerError,
+			s.MaxCornerError, s.MeanCellIoU, s.CellsMatched, alignment.SudokuGridDimension * alignment.SudokuGridDimension)
+	}
+	fmt.Fprintf(w, "\n%d images\n", len(r.Scores))
+	fmt.Fprintf(w, "corner error: mean %.2fpx, median %.2fpx, max %.2fpx\n", r.MeanCornerError, r.MedianCornerError, r.MaxCornerError)
+	fmt.Fprintf(w, "cell IoU: mean %.3f, %.1f%% of cells >= %.2f\n", r.MeanCellIoU, 100.0 * r.CellMatchRate, CellMatchIoU)
+	fmt.Fprintf(w, "align time: %.2fs/img\n", r.MeanSeconds)
+}
+
+// EvaluateRuns aligns every image runs times, seeding the aligner with
+// p.Seed, p.Seed + 1, and so on. the report pools the scores of every
+// run, spread is the standard deviation of the runs' MeanCornerError, to
+// tell a real difference between settings from a lucky seed.
+func EvaluateRuns(p alignment.EdgeDetectorParams, imgs []LabeledImage, runs int) (r EvalReport, spread float64, err error) {
+	var scores []AlignmentScore
+	var errs []float64
+	for k := 0; k < max(runs, 1); k++ {
+		q := p
+		q.Seed = p.Seed + int64(k)
+		run, err := EvaluateParams(q, imgs)
+		if err != nil {
+			return r, 0.0, err
+		}
+		scores = append(scores, run.Scores...)
+		errs = append(errs, run.MeanCornerError)
+	}
+	mean := 0.0
+	for _, e := range errs {
+		mean += e / float64(len(errs))
+	}
+	for _, e := range errs {
+		spread += (e - mean) * (e - mean) / float64(len(errs))
+	}
+	return Summarize(scores), math.Sqrt(spread), nil
+}
+
+// aligns every image in the dataset with p, bending the lines afterwards
+// if p.BendIterations > 0, and scores whichever fit came out last
+func EvaluateParams(p alignment.EdgeDetectorParams, imgs []LabeledImage) (EvalReport, error) {
+	scores := make([]AlignmentScore, len(imgs))
+	for i, li := range imgs {
+		img, err := imaging.OpenImage(li.Path)
+		if err != nil {
+			return EvalReport{}, err
+		}
+		start := time.Now()
+		ed := alignment.NewEdgeDetector(geometry.NewFloat64Rectangle(img.Bounds()), p)
+		if ed, err = ed.AlignTo(img, nil); err != nil {
+			return EvalReport{}, fmt.Errorf("[EvaluateParams] %s: %w", li.Path, err)
+		}
+		var fit Fit = ed
+		if p.BendIterations > 0 {
+			grid, err := alignment.NewCurvedGrid(ed).AlignTo(img, nil)
+			if err != nil {
+				return EvalReport{}, fmt.Errorf("[EvaluateParams] %s: %w", li.Path, err)
+			}
+			fit = grid
+		}
+		scores[i] = ScoreAlignment(fit, li.Truth)
+		scores[i].Path = li.Path
+		scores[i].Seconds = time.Since(start).Seconds()
+	}
+	return Summarize(scores), nil
+}
@@ -0,0 +1,104 @@
+package evaluation
+
+import (
+	"image"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/twolfe18/sudoku/alignment"
+	"github.com/twolfe18/sudoku/geometry"
+	"github.com/twolfe18/sudoku/imaging"
+	"github.com/twolfe18/sudoku/synth"
+)
+
+// an unperturbed lattice, and an annotation that matches it exactly
+func perfectFit() (alignment.EdgeDetector, alignment.Annotation) {
+	p := alignment.DefaultEdgeDetectorParams()
+	p.Crappyness = 0.0
+	p.Padding = 20.0
+	p.NumLines = alignment.SudokuGridDimension + 1
+	ed := alignment.NewEdgeDetector(geometry.NewFloat64Rectangle(image.Rect(0, 0, 200, 200)), p)
+	return ed, alignment.Annotation{Corners: ed.Corners(), Cells: make([]int, 81)}
+}
+
+func TestScoreAlignment(t *testing.T) {
+	ed, truth := perfectFit()
+	for name, fit := range map[string]Fit{"straight": ed, "curved": alignment.NewCurvedGrid(ed)} {
+		s := ScoreAlignment(fit, truth)
+		if s.MeanCornerError > 1e-6 || math.Abs(s.MeanCellIoU - 1.0) > 1e-6 || s.CellsMatched != 81 {
+			t.Errorf("%s: a perfect fit scored %+v", name, s)
+		}
+	}
+	shifted := truth
+	for i := range shifted.Corners {
+		shifted.Corners[i].Shift(3.0, 4.0)
+	}
+	if s := ScoreAlignment(ed, shifted); math.Abs(s.MeanCornerError - 5.0) > 1e-9 || math.Abs(s.MaxCornerError - 5.0) > 1e-9 {
+		t.Errorf("fit off by (3, 4) scored %+v", s)
+	}
+}
+
+// with bend_iterations set the bent grid is what gets scored, so it
+// should come out different from the straight fit on the same seed
+func TestEvaluateParamsBends(t *testing.T) {
+	dir := t.TempDir()
+	img, a, err := synth.Render(make([]int, 81), synth.DefaultSynthParams(), rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "board.png")
+	if err = imaging.SaveImage(img, path); err != nil {
+		t.Fatal(err)
+	}
+	if err = a.Save(alignment.AnnotationPath(path)); err != nil {
+		t.Fatal(err)
+	}
+	imgs, err := LoadDataset(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := alignment.DefaultEdgeDetectorParams()
+	p.NumIterations = 3
+	p.NumProposals = 10
+	straight, err := EvaluateParams(p, imgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.BendIterations = 3
+	bent, err := EvaluateParams(p, imgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bent.MeanCellIoU == straight.MeanCellIoU && bent.MeanCornerError == straight.MeanCornerError {
+		t.Errorf("bending made no difference to the score, was the curved grid scored?")
+	}
+	if math.IsNaN(bent.MeanCellIoU) || bent.MeanCellIoU <= 0.0 {
+		t.Errorf("bent fit has cell IoU %v", bent.MeanCellIoU)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	scores := func(errs ...float64) (s []AlignmentScore) {
+		for _, e := range errs {
+			s = append(s, AlignmentScore{MeanCornerError: e, MaxCornerError: 2 * e, MeanCellIoU: 0.5, CellsMatched: 27, Seconds: 1.0})
+		}
+		return s
+	}
+	odd := Summarize(scores(5.0, 1.0, 3.0))
+	if odd.MedianCornerError != 3.0 || odd.MeanCornerError != 3.0 || odd.MaxCornerError != 10.0 {
+		t.Errorf("odd count: %+v", odd)
+	}
+	even := Summarize(scores(4.0, 1.0, 10.0, 2.0))
+	if even.MedianCornerError != 3.0 || even.MeanCornerError != 4.25 {
+		t.Errorf("even count: median %g, mean %g", even.MedianCornerError, even.MeanCornerError)
+	}
+	if math.Abs(even.CellMatchRate - 1.0 / 3.0) > 1e-12 || even.MeanCellIoU != 0.5 || even.MeanSeconds != 1.0 {
+		t.Errorf("rates: %+v", even)
+	}
+	if empty := Summarize(nil); empty.MeanCornerError != 0.0 || len(empty.Scores) != 0 {
+		t.Errorf("no scores: %+v", empty)
+	}
+}
@@ -0,0 +1,136 @@
+package evaluation
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/twolfe18/sudoku/config"
+)
+
+// a tuning run searches over the named EdgeDetector flags (see config.Config.RegisterFlags)
+// and scores each setting by how close AlignTo gets to the annotated board corners.
+
+// one dimension of the search, values are spread evenly over [Lo, Hi]
+type ParamRange struct {
+	Name string
+	Lo, Hi float64
+	Steps int
+}
+
+// parses "ed.greedyness=1:4:4,ed.num_proposals=25:100:4" (name=lo:hi:steps)
+func ParseParamRanges(spec string) (ranges []ParamRange, err error) {
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" { continue }
+		kv := strings.Split(field, "=")
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("[ParseParamRanges] expected name=lo:hi:steps, got %q", field)
+		}
+		parts := strings.Split(kv[1], ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("[ParseParamRanges] expected lo:hi:steps for %s, got %q", kv[0], kv[1])
+		}
+		var r ParamRange
+		r.Name = kv[0]
+		if r.Lo, err = strconv.ParseFloat(parts[0], 64); err != nil {
+			return nil, fmt.Errorf("[ParseParamRanges] bad lo for %s: %s", r.Name, err)
+		}
+		if r.Hi, err = strconv.ParseFloat(parts[1], 64); err != nil {
+			return nil, fmt.Errorf("[ParseParamRanges] bad hi for %s: %s", r.Name, err)
+		}
+		if r.Steps, err = strconv.Atoi(parts[2]); err != nil || r.Steps < 1 {
+			return nil, fmt.Errorf("[ParseParamRanges] bad steps for %s: %q", r.Name, parts[2])
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+func (r ParamRange) Value(step int) float64 {
+	if r.Steps == 1 { return r.Lo }
+	return r.Lo + (r.Hi - r.Lo) * float64(step) / float64(r.Steps - 1)
+}
+
+func (r ParamRange) Sample(rng *rand.Rand) float64 {
+	return r.Lo + rng.Float64() * (r.Hi - r.Lo)
+}
+
+// a point in the search space: flag name -> value
+type Setting map[string]float64
+
+func (s Setting) String() string {
+	keys := make([]string, 0, len(s))
+	for k, _ := range s { keys = append(keys, k) }
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%g", k, s[k])
+	}
+	return strings.Join(parts, " ")
+}
+
+// applies the setting on top of base by going through the same flags the CLI uses
+func (s Setting) Apply(base config.Config) (c config.Config, err error) {
+	c = base
+	fs := flag.NewFlagSet("tune", flag.ContinueOnError)
+	c.RegisterFlags(fs)
+	for k, v := range s {
+		if fs.Lookup(k) == nil {
+			return c, fmt.Errorf("[Setting.Apply] unknown parameter %s", k)
+		}
+		// integer flags won't take "37.5"
+		if err = fs.Set(k, fmt.Sprintf("%g", v)); err != nil {
+			if err = fs.Set(k, fmt.Sprintf("%d", int(math.Floor(v + 0.5)))); err != nil {
+				return c, fmt.Errorf("[Setting.Apply] can't set %s to %g: %s", k, v, err)
+			}
+		}
+	}
+	return c, c.Validate()
+}
+
+// every combination of the ranges
+func GridSettings(ranges []ParamRange) []Setting {
+	settings := []Setting{Setting{}}
+	for _, r := range ranges {
+		next := make([]Setting, 0, len(settings) * r.Steps)
+		for _, s := range settings {
+			for i := 0; i < r.Steps; i++ {
+				ns := Setting{}
+				for k, v := range s { ns[k] = v }
+				ns[r.Name] = r.Value(i)
+				next = append(next, ns)
+			}
+		}
+		settings = next
+	}
+	return settings
+}
+
+// n settings drawn uniformly from the box given by the ranges (Steps is ignored)
+func RandomSettings(ranges []ParamRange, n int, rng *rand.Rand) []Setting {
+	settings := make([]Setting, n)
+	for i := range settings {
+		settings[i] = Setting{}
+		for _, r := range ranges {
+			settings[i][r.Name] = r.Sample(rng)
+		}
+	}
+	return settings
+}
+
+type TuneResult struct {
+	Setting Setting
+	Report EvalReport	// every run's scores together
+	Spread float64	// see EvaluateRuns
+}
+
+type ByMeanError []TuneResult
+
+func (r ByMeanError) Len() int { return len(r) }
+func (r ByMeanError) Less(i, j int) bool { return r[i].Report.MeanCornerError < r[j].Report.MeanCornerError }
+func (r ByMeanError) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
@@ -1,9 +1,9 @@
 
-package main
+package geometry
 
 import (
 	"fmt"
-	"rand"
+	"math/rand"
 	"math"
 	"image"
 )
@@ -14,7 +14,7 @@ type Float64Point struct {
 
 func (p Float64Point) Equals(o Float64Point) bool {
 	const ep = 1e-4
-	return math.Fabs(p.X-o.X) < ep && math.Fabs(p.Y-o.Y) < ep
+	return math.Abs(p.X-o.X) < ep && math.Abs(p.Y-o.Y) < ep
 }
 
 func NewFloat64Point(p image.Point) (fp Float64Point) {
@@ -38,10 +38,10 @@ func (p *Float64Point) Shift(dx, dy float64) {
 }
 
 func (p *Float64Point) ProjectInto(bounds Float64Rectangle) {
-	p.X = math.Fmax(bounds.Min.X, p.X)
-	p.X = math.Fmin(bounds.Max.X, p.X)
-	p.Y = math.Fmax(bounds.Min.Y, p.Y)
-	p.Y = math.Fmin(bounds.Max.Y, p.Y)
+	p.X = math.Max(bounds.Min.X, p.X)
+	p.X = math.Min(bounds.Max.X, p.X)
+	p.Y = math.Max(bounds.Min.Y, p.Y)
+	p.Y = math.Min(bounds.Max.Y, p.Y)
 }
 
 func (v *Float64Point) Rotate(theta float64) {
@@ -0,0 +1,114 @@
+package geometry
+
+import (
+	"image"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+// a coordinate in a sane range for quick.Check, the default
+// float64 generator happily produces 1e308
+type coord float64
+
+func (coord) Generate(r *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(coord((r.Float64() * 2.0 - 1.0) * 1000.0))
+}
+
+func pt(x, y coord) Float64Point {
+	return Float64Point{float64(x), float64(y)}
+}
+
+func close(a, b float64) bool {
+	return math.Abs(a - b) < 1e-6 * math.Max(1.0, math.Max(math.Abs(a), math.Abs(b)))
+}
+
+var quickConfig = &quick.Config{MaxCount: 1000}
+
+func TestRotate(t *testing.T) {
+	p := Float64Point{1.0, 0.0}
+	p.Rotate(math.Pi / 2.0)
+	if !p.Equals(Float64Point{0.0, 1.0}) {
+		t.Errorf("(1,0) rotated 90 degrees = %s, want (0,1)", p)
+	}
+	p = Float64Point{3.0, 4.0}
+	p.Rotate(math.Pi)
+	if !p.Equals(Float64Point{-3.0, -4.0}) {
+		t.Errorf("(3,4) rotated 180 degrees = %s, want (-3,-4)", p)
+	}
+}
+
+func TestRotateProperties(t *testing.T) {
+	// rotation keeps length and is undone by rotating back
+	f := func(x, y, theta coord) bool {
+		p := pt(x, y)
+		q := p
+		q.Rotate(float64(theta))
+		if !close(p.L2Norm(), q.L2Norm()) {
+			return false
+		}
+		q.Rotate(-float64(theta))
+		return close(p.X, q.X) && close(p.Y, q.Y)
+	}
+	if err := quick.Check(f, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProjectInto(t *testing.T) {
+	b := Float64Rectangle{Float64Point{0.0, 0.0}, Float64Point{10.0, 20.0}}
+	cases := []struct{ in, out Float64Point }{
+		{Float64Point{5.0, 5.0}, Float64Point{5.0, 5.0}},
+		{Float64Point{-1.0, 5.0}, Float64Point{0.0, 5.0}},
+		{Float64Point{11.0, 25.0}, Float64Point{10.0, 20.0}},
+		{Float64Point{-3.0, -3.0}, Float64Point{0.0, 0.0}},
+	}
+	for _, c := range cases {
+		p := c.in
+		p.ProjectInto(b)
+		if !p.Equals(c.out) {
+			t.Errorf("%s projected into %s-%s = %s, want %s", c.in, b.Min, b.Max, p, c.out)
+		}
+	}
+}
+
+func TestProjectIntoProperties(t *testing.T) {
+	b := Float64Rectangle{Float64Point{-100.0, -50.0}, Float64Point{100.0, 50.0}}
+	f := func(x, y coord) bool {
+		p := pt(x, y)
+		q := p
+		q.ProjectInto(b)
+		inside := b.Min.X <= q.X && q.X <= b.Max.X && b.Min.Y <= q.Y && q.Y <= b.Max.Y
+		was_inside := b.Min.X <= p.X && p.X <= b.Max.X && b.Min.Y <= p.Y && p.Y <= b.Max.Y
+		return inside && (!was_inside || p.Equals(q))
+	}
+	if err := quick.Check(f, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	if d := Distance(Float64Point{1.0, 1.0}, Float64Point{4.0, 5.0}); !close(d, 5.0) {
+		t.Errorf("distance = %.4f, want 5", d)
+	}
+	f := func(ax, ay, bx, by, cx, cy coord) bool {
+		a, b, c := pt(ax, ay), pt(bx, by), pt(cx, cy)
+		return close(Distance(a, b), Distance(b, a)) &&
+			Distance(a, c) <= Distance(a, b) + Distance(b, c) + 1e-9
+	}
+	if err := quick.Check(f, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRectangle(t *testing.T) {
+	r := NewFloat64Rectangle(image.Rect(2, 3, 12, 8))
+	if r.Dx() != 10.0 || r.Dy() != 5.0 {
+		t.Errorf("rect (2,3)-(12,8) has Dx = %.1f, Dy = %.1f, want 10, 5", r.Dx(), r.Dy())
+	}
+	if !r.Min.Equals(Float64Point{2.0, 3.0}) || !r.Max.Equals(Float64Point{12.0, 8.0}) {
+		t.Errorf("rect (2,3)-(12,8) converted to %s-%s", r.Min, r.Max)
+	}
+}
@@ -0,0 +1,77 @@
+package geometry
+
+import "math"
+
+// a projective transform of the plane, row major 3x3 with H[8] = 1
+type Homography [9]float64
+
+func IdentityHomography() Homography {
+	return Homography{1, 0, 0, 0, 1, 0, 0, 0, 1}
+}
+
+// the transform taking each src[i] to dst[i], ok is false if three
+// of the src points are collinear. if three dst points are, h is singular
+// (see Inverse).
+func HomographyFromQuads(src, dst [4]Float64Point) (h Homography, ok bool) {
+	// each correspondence gives two rows of an 8x8 system in h[0..7]
+	var a [8][9]float64
+	for i := 0; i < 4; i++ {
+		x, y := src[i].X, src[i].Y
+		X, Y := dst[i].X, dst[i].Y
+		a[2*i] = [9]float64{x, y, 1, 0, 0, 0, -x * X, -y * X, X}
+		a[2*i+1] = [9]float64{0, 0, 0, x, y, 1, -x * Y, -y * Y, Y}
+	}
+	// gaussian elimination with partial pivoting
+	for col := 0; col < 8; col++ {
+		pivot := col
+		for r := col + 1; r < 8; r++ {
+			if math.Abs(a[r][col]) > math.Abs(a[pivot][col]) { pivot = r }
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return h, false
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		for r := 0; r < 8; r++ {
+			if r == col { continue }
+			f := a[r][col] / a[col][col]
+			for c := col; c < 9; c++ {
+				a[r][c] -= f * a[col][c]
+			}
+		}
+	}
+	for i := 0; i < 8; i++ {
+		h[i] = a[i][8] / a[i][i]
+	}
+	h[8] = 1.0
+	return h, true
+}
+
+func (h Homography) Apply(p Float64Point) Float64Point {
+	w := h[6] * p.X + h[7] * p.Y + h[8]
+	return Float64Point{
+		(h[0] * p.X + h[1] * p.Y + h[2]) / w,
+		(h[3] * p.X + h[4] * p.Y + h[5]) / w,
+	}
+}
+
+// ok is false if h is singular
+func (h Homography) Inverse() (inv Homography, ok bool) {
+	// adjugate over determinant
+	inv[0] = h[4] * h[8] - h[5] * h[7]
+	inv[1] = h[2] * h[7] - h[1] * h[8]
+	inv[2] = h[1] * h[5] - h[2] * h[4]
+	inv[3] = h[5] * h[6] - h[3] * h[8]
+	inv[4] = h[0] * h[8] - h[2] * h[6]
+	inv[5] = h[2] * h[3] - h[0] * h[5]
+	inv[6] = h[3] * h[7] - h[4] * h[6]
+	inv[7] = h[1] * h[6] - h[0] * h[7]
+	inv[8] = h[0] * h[4] - h[1] * h[3]
+	det := h[0] * inv[0] + h[1] * inv[3] + h[2] * inv[6]
+	if math.Abs(det) < 1e-12 {
+		return inv, false
+	}
+	for i := range inv {
+		inv[i] /= det
+	}
+	return inv, true
+}
@@ -0,0 +1,37 @@
+package geometry
+
+import "testing"
+
+func TestHomographyFromQuads(t *testing.T) {
+	unit := [4]Float64Point{{0, 0}, {1, 0}, {1, 1}, {0, 1}}
+	quad := [4]Float64Point{{30.5, 20.25}, {220, 35}, {235.75, 240}, {12, 210.5}}
+	h, ok := HomographyFromQuads(unit, quad)
+	if !ok {
+		t.Fatalf("a proper quad should work")
+	}
+	for i := range unit {
+		if got := h.Apply(unit[i]); !close(got.X, quad[i].X) || !close(got.Y, quad[i].Y) {
+			t.Errorf("corner %d went to %s, want %s", i, got, quad[i])
+		}
+	}
+	inv, ok := h.Inverse()
+	if !ok {
+		t.Fatalf("no inverse")
+	}
+	p := Float64Point{0.3, 0.8}
+	if back := inv.Apply(h.Apply(p)); !close(back.X, p.X) || !close(back.Y, p.Y) {
+		t.Errorf("inverse took %s back to %s", p, back)
+	}
+	if id, _ := HomographyFromQuads(quad, quad); !close(id.Apply(p).X, p.X) || !close(id.Apply(p).Y, p.Y) {
+		t.Errorf("a quad onto itself should be the identity, got %v", id)
+	}
+	if _, ok := HomographyFromQuads([4]Float64Point{{0, 0}, {1, 1}, {2, 2}, {0, 1}}, quad); ok {
+		t.Errorf("three collinear corners should fail")
+	}
+	// collinear on the other side solves, but squashes the plane flat
+	if h, ok := HomographyFromQuads(unit, [4]Float64Point{{0, 0}, {1, 1}, {2, 2}, {0, 1}}); ok {
+		if _, ok = h.Inverse(); ok {
+			t.Errorf("a map onto three collinear corners can't be undone")
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package geometry
+
+import "math"
+
+// vertices in order, the last one connects back to the first
+type Polygon []Float64Point
+
+// signed area by the shoelace formula, positive when counter-clockwise
+// in a y-up frame (clockwise on screen)
+func (poly Polygon) SignedArea() float64 {
+	a := 0.0
+	for i, p := range poly {
+		q := poly[(i+1) % len(poly)]
+		a += p.X * q.Y - q.X * p.Y
+	}
+	return a / 2.0
+}
+
+func (poly Polygon) Area() float64 {
+	return math.Abs(poly.SignedArea())
+}
+
+// the part of poly inside clip, which must be convex (Sutherland-Hodgman)
+func (poly Polygon) Clip(clip Polygon) Polygon {
+	if len(poly) < 3 || len(clip) < 3 {
+		return nil
+	}
+	// inside means left of each directed edge, flip clip if it winds the other way
+	if clip.SignedArea() < 0.0 {
+		rev := make(Polygon, len(clip))
+		for i, p := range clip {
+			rev[len(clip)-1-i] = p
+		}
+		clip = rev
+	}
+	out := poly
+	for i, a := range clip {
+		b := clip[(i+1) % len(clip)]
+		in := out
+		out = nil
+		if len(in) == 0 { break }
+		inside := func(p Float64Point) bool {
+			return (b.X - a.X) * (p.Y - a.Y) - (b.Y - a.Y) * (p.X - a.X) >= 0.0
+		}
+		cross := func(p, q Float64Point) Float64Point {
+			x, ok := Intersection(Line{p, q, 0.0}, Line{a, b, 0.0})
+			if !ok {
+				return q	// p and q both on the edge, rounding put one outside
+			}
+			return x
+		}
+		prev := in[len(in)-1]
+		for _, cur := range in {
+			if inside(cur) {
+				if !inside(prev) {
+					out = append(out, cross(prev, cur))
+				}
+				out = append(out, cur)
+			} else if inside(prev) {
+				out = append(out, cross(prev, cur))
+			}
+			prev = cur
+		}
+	}
+	return out
+}
+
+// intersection over union, clip must be convex
+func IoU(poly, clip Polygon) float64 {
+	inter := poly.Clip(clip).Area()
+	union := poly.Area() + clip.Area() - inter
+	if union <= 0.0 {
+		return 0.0
+	}
+	return inter / union
+}
+
+// whether p is inside poly by the even-odd rule, for any simple polygon
+func (poly Polygon) Contains(p Float64Point) bool {
+	in := false
+	for i, a := range poly {
+		b := poly[(i+1) % len(poly)]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < a.X + (p.Y - a.Y) * (b.X - a.X) / (b.Y - a.Y) {
+			in = !in
+		}
+	}
+	return in
+}
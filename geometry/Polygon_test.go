@@ -0,0 +1,66 @@
+package geometry
+
+import "testing"
+
+func TestClipAlongAnEdge(t *testing.T) {
+	clip := Polygon{{85, 85}, {95, 85}, {95, 95}, {85, 95}}
+	// the same square with extra points on its sides, one rounded a hair outside
+	poly := Polygon{{85, 85}, {90, 85}, {95, 85}, {95, 95}, {92.5, 95}, {90, 95 + 1e-13}, {87.5, 95}, {85, 95}}
+	if iou := IoU(poly, clip); !close(iou, 1.0) {
+		t.Errorf("IoU of a square with itself is %.4f", iou)
+	}
+}
+
+func square(x, y, side float64) Polygon {
+	return Polygon{{x, y}, {x + side, y}, {x + side, y + side}, {x, y + side}}
+}
+
+func reversed(p Polygon) (r Polygon) {
+	for i := len(p) - 1; i >= 0; i-- {
+		r = append(r, p[i])
+	}
+	return r
+}
+
+func TestSignedArea(t *testing.T) {
+	sq := square(0, 0, 2)
+	if a := sq.SignedArea(); !close(a, 4.0) {
+		t.Errorf("2x2 square has signed area %g", a)
+	}
+	if a := reversed(sq).SignedArea(); !close(a, -4.0) {
+		t.Errorf("the other way round it should be -4, got %g", a)
+	}
+	if a := (Polygon{{0, 0}, {4, 0}, {0, 3}}).Area(); !close(a, 6.0) {
+		t.Errorf("3-4-5 triangle has area %g", a)
+	}
+}
+
+func TestIoU(t *testing.T) {
+	a := square(0, 0, 1)
+	cases := []struct {
+		name string
+		b Polygon
+		want float64
+	}{
+		{"identical", square(0, 0, 1), 1.0},
+		{"disjoint", square(5, 5, 1), 0.0},
+		{"touching", square(1, 0, 1), 0.0},
+		{"half overlapping", square(0.5, 0, 1), 0.5 / 1.5},
+		{"inside", square(0.25, 0.25, 0.5), 0.25},
+	}
+	for _, c := range cases {
+		for _, w := range []struct {
+			name string
+			poly, clip Polygon
+		}{
+			{"", a, c.b},
+			{" poly reversed", reversed(a), c.b},
+			{" clip reversed", a, reversed(c.b)},
+			{" swapped", c.b, a},
+		} {
+			if got := IoU(w.poly, w.clip); !close(got, c.want) {
+				t.Errorf("%s%s: IoU %.4f, want %.4f", c.name, w.name, got, c.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package geometry
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// a quadratic Bézier from Left to Right, pulled toward Control. grid lines
+// on a bowed page are close to this. a straight Line is the curve with
+// Control at its midpoint.
+type Curve struct {
+	Left, Control, Right Float64Point
+	Radius float64		// std deviation of gaussian off the curve, as in Line
+}
+
+// how many segments a curve is cut into by Segments and everything built on it
+const CurveSegments = 16
+
+func StraightCurve(l Line) Curve {
+	return Curve{l.Left, l.Midpoint(), l.Right, l.Radius}
+}
+
+// the point t of the way along, t = 0 is Left and t = 1 is Right. t outside
+// [0, 1] continues the parabola.
+func (c Curve) At(t float64) Float64Point {
+	a, b, d := (1.0 - t) * (1.0 - t), 2.0 * t * (1.0 - t), t * t
+	return Float64Point{
+		a * c.Left.X + b * c.Control.X + d * c.Right.X,
+		a * c.Left.Y + b * c.Control.Y + d * c.Right.Y,
+	}
+}
+
+// the derivative of At
+func (c Curve) tangent(t float64) Float64Point {
+	return Float64Point{
+		2.0 * (1.0 - t) * (c.Control.X - c.Left.X) + 2.0 * t * (c.Right.X - c.Control.X),
+		2.0 * (1.0 - t) * (c.Control.Y - c.Left.Y) + 2.0 * t * (c.Right.Y - c.Control.Y),
+	}
+}
+
+// the straight line between the ends
+func (c Curve) Chord() Line {
+	return Line{c.Left, c.Right, c.Radius}
+}
+
+// unit normal of the chord, to the left when walking from Left to Right
+// in a y-up frame. zero for a zero length chord.
+func (c Curve) normal() (n Float64Point) {
+	d := PointMinus(c.Right, c.Left)
+	if l := d.L2Norm(); l > 0.0 {
+		n = Float64Point{-d.Y / l, d.X / l}
+	}
+	return n
+}
+
+// how far the middle of the curve sits off its chord along the chord's
+// normal, in pixels. 0 for a straight curve.
+func (c Curve) Bend() float64 {
+	return DotProduct(PointMinus(c.At(0.5), c.Chord().Midpoint()), c.normal())
+}
+
+// moves Control so the middle of the curve is b off the chord. the middle
+// moves half as far as the control point does.
+func (c *Curve) SetBend(b float64) {
+	n := c.normal()
+	n.Scale(2.0 * b)
+	c.Control = PointPlus(c.Chord().Midpoint(), n)
+}
+
+func (c *Curve) Shift(dx, dy float64) {
+	c.Left.Shift(dx, dy)
+	c.Control.Shift(dx, dy)
+	c.Right.Shift(dx, dy)
+}
+
+// the curve as CurveSegments straight pieces, each with the curve's Radius
+func (c Curve) Segments() []Line {
+	segs := make([]Line, CurveSegments)
+	prev := c.Left
+	for i := range segs {
+		next := c.At(float64(i + 1) / CurveSegments)
+		segs[i] = Line{prev, next, c.Radius}
+		prev = next
+	}
+	return segs
+}
+
+// distance from p to the closest point on the curve (to within how well
+// Segments follows it)
+func (c Curve) Distance(p Float64Point) float64 {
+	d := math.Inf(1)
+	for _, s := range c.Segments() {
+		d = math.Min(d, s.SegmentDistance(p))
+	}
+	return d
+}
+
+// like Line.WeightedIterator, each pixel within FootprintRadii * Radius of
+// the curve once, weighted by a gaussian in its distance to the curve
+func (c Curve) WeightedIterator(bounds image.Rectangle) (pix []WeightedPoint) {
+	seen := make(map[image.Point]int)
+	for _, s := range c.Segments() {
+		for _, wp := range s.WeightedIterator(bounds) {
+			if i, ok := seen[wp.P]; ok {
+				// closest segment wins
+				pix[i].W = math.Max(pix[i].W, wp.W)
+				continue
+			}
+			seen[wp.P] = len(pix)
+			pix = append(pix, wp)
+		}
+	}
+	return pix
+}
+
+func (c Curve) String() string {
+	return fmt.Sprintf("[%s -> %s -> %s]", c.Left.String(), c.Control.String(), c.Right.String())
+}
+
+// where a and b cross, and how far along each (as in At) that is. starts
+// from where the chords cross and polishes with newton's method, so for
+// gentle curves the crossing can be a little past either end. ok is false
+// for parallel chords or if newton's method doesn't settle.
+func CurveIntersection(a, b Curve) (p Float64Point, ta, tb float64, ok bool) {
+	ta, tb, ok = crossParams(a.Chord(), b.Chord())
+	if !ok {
+		return p, ta, tb, false
+	}
+	for iter := 0; iter < 20; iter++ {
+		// solve a.At(ta) - b.At(tb) = 0
+		f := PointMinus(a.At(ta), b.At(tb))
+		if f.L2Norm() < 1e-9 {
+			return a.At(ta), ta, tb, true
+		}
+		da, db := a.tangent(ta), b.tangent(tb)
+		det := -da.X * db.Y + db.X * da.Y
+		if math.Abs(det) < 1e-12 {
+			return a.At(ta), ta, tb, false
+		}
+		ta -= (-db.Y * f.X + db.X * f.Y) / det
+		tb -= (-da.Y * f.X + da.X * f.Y) / det
+	}
+	p = a.At(ta)
+	return p, ta, tb, Distance(p, b.At(tb)) < 1e-6
+}
@@ -0,0 +1,96 @@
+package geometry
+
+import (
+	"image"
+	"testing"
+	"testing/quick"
+)
+
+func TestStraightCurve(t *testing.T) {
+	l := Line{Float64Point{2.0, 3.0}, Float64Point{40.0, 9.0}, 1.0}
+	c := StraightCurve(l)
+	if !close(c.Bend(), 0.0) || !c.At(0.5).Equals(l.Midpoint()) {
+		t.Errorf("%s should be straight", c)
+	}
+	p := Float64Point{20.0, -5.0}
+	if !close(c.Distance(p), l.SegmentDistance(p)) {
+		t.Errorf("distance %.4f, line says %.4f", c.Distance(p), l.SegmentDistance(p))
+	}
+}
+
+func TestSetBendProperties(t *testing.T) {
+	f := func(ax, ay, bx, by, b coord) bool {
+		c := StraightCurve(seg(ax, ay, bx, by))
+		if c.Chord().Dx() == 0.0 && c.Chord().Dy() == 0.0 { return true }
+		c.SetBend(float64(b) / 10.0)
+		m := c.At(0.5)
+		return close(c.Bend(), float64(b) / 10.0) && close(c.Chord().Distance(m.X, m.Y), abs(float64(b) / 10.0))
+	}
+	if err := quick.Check(f, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func abs(x float64) float64 {
+	if x < 0.0 { return -x }
+	return x
+}
+
+func TestCurveIntersection(t *testing.T) {
+	h := StraightCurve(Line{Float64Point{0.0, 10.0}, Float64Point{50.0, 12.0}, 1.0})
+	v := StraightCurve(Line{Float64Point{20.0, 0.0}, Float64Point{22.0, 50.0}, 1.0})
+	want, _ := Intersection(h.Chord(), v.Chord())
+	if p, _, _, ok := CurveIntersection(h, v); !ok || !p.Equals(want) {
+		t.Errorf("straight curves cross at %s, want %s", p, want)
+	}
+
+	// bent, the crossing is on both curves and not where the chords cross
+	h.SetBend(6.0)
+	v.SetBend(-4.0)
+	p, th, tv, ok := CurveIntersection(h, v)
+	if !ok || !p.Equals(h.At(th)) || !p.Equals(v.At(tv)) {
+		t.Errorf("bent curves cross at %s, but that is %s on h and %s on v", p, h.At(th), v.At(tv))
+	}
+	if p.Equals(want) {
+		t.Errorf("bending didn't move the crossing")
+	}
+
+	parallel := StraightCurve(Line{Float64Point{0.0, 20.0}, Float64Point{50.0, 22.0}, 1.0})
+	if _, _, _, ok := CurveIntersection(StraightCurve(h.Chord()), parallel); ok {
+		t.Errorf("parallel chords should not cross")
+	}
+}
+
+func TestCurveWeightedIterator(t *testing.T) {
+	bounds := image.Rect(0, 0, 64, 64)
+	l := Line{Float64Point{5.0, 7.5}, Float64Point{50.0, 30.0}, 1.5}
+	want := make(map[image.Point]float64)
+	for _, wp := range l.WeightedIterator(bounds) {
+		want[wp.P] = wp.W
+	}
+	// a straight curve covers what its line does
+	got := StraightCurve(l).WeightedIterator(bounds)
+	if len(got) != len(want) {
+		t.Fatalf("%d pixels, line has %d", len(got), len(want))
+	}
+	for _, wp := range got {
+		if w, ok := want[wp.P]; !ok || !close(w, wp.W) {
+			t.Errorf("%v has weight %.4f, line says %.4f", wp.P, wp.W, w)
+		}
+	}
+
+	// and a bent one still visits each pixel once, heaviest on the curve
+	c := StraightCurve(l)
+	c.SetBend(8.0)
+	seen := make(map[image.Point]bool)
+	for _, wp := range c.WeightedIterator(bounds) {
+		if seen[wp.P] {
+			t.Fatalf("%v visited twice", wp.P)
+		}
+		seen[wp.P] = true
+	}
+	m := c.At(0.5)
+	if mp := (image.Point{int(m.X + 0.5), int(m.Y + 0.5)}); !seen[mp] {
+		t.Errorf("the middle of the curve %v was not visited", mp)
+	}
+}
@@ -0,0 +1,259 @@
+
+package geometry
+
+import (
+	"image"
+	"math"
+	"fmt"
+)
+
+/******************************************************************************************/
+
+type Line struct {
+	Left, Right Float64Point
+	Radius float64		// std deviation of gaussian off the normal of the line
+}
+
+func (l Line) Equals(o Line) bool {
+	const ep = 1e-4
+	return math.Abs(l.Radius-o.Radius) < ep && l.Left.Equals(o.Left) && l.Right.Equals(o.Right)
+}
+
+func HorizontalLine() Line{
+	return Line{Float64Point{0.0, 0.0}, Float64Point{1.0, 0.0}, 0.0}
+}
+
+func VerticalLine() Line{
+	return Line{Float64Point{0.0, 0.0}, Float64Point{0.0, 1.0}, 0.0}
+}
+
+/******************************************************************************************/
+
+func (l Line) Dx() float64 {
+	return l.Right.X - l.Left.X
+}
+
+func (l Line) Dy() float64 {
+	return l.Right.Y - l.Left.Y
+}
+
+func (l Line) Midpoint() (mid Float64Point) {
+	v := PointMinus(l.Right, l.Left)
+	v.Scale(0.5)
+	return PointPlus(l.Left, v)
+}
+
+func (l *Line) ScaleLength(scale float64) {
+	m := l.Midpoint()
+	v := PointMinus(l.Right, m)
+	v.Scale(scale)
+	l.Right = PointPlus(m, v)
+	l.Left = PointMinus(m, v)
+}
+
+func (l *Line) Shift(dx, dy float64) {
+	l.Left.Shift(dx, dy)
+	l.Right.Shift(dx, dy)
+}
+
+func (l *Line) ProjectInto(bounds Float64Rectangle) {
+	l.Left.ProjectInto(bounds)
+	l.Right.ProjectInto(bounds)
+}
+
+// this allows for stuff like anti-aliased drawing
+type WeightedPoint struct {
+	P image.Point
+	W float64
+}
+
+// it would be nice to use goroutines for the interator, but
+// it appears to be way too slow:
+// http://groups.google.com/group/golang-nuts/browse_thread/thread/a717e1286a8736fd#
+// for now i'll use a fully blocking producer-consumer model
+func (l Line) UnweightedIterator() (pix []WeightedPoint) {
+
+	cur := l.Left
+	// round up so no step is longer than a pixel
+	iter := int(math.Ceil(math.Max(math.Abs(l.Dx()), math.Abs(l.Dy()))))
+	if iter == 0 {
+		p := image.Point{int(l.Left.X), int(l.Left.Y)}
+		return append(pix, WeightedPoint{p, 1.0})
+	}
+	dx := l.Dx() / float64(iter); dy := l.Dy() / float64(iter)
+	for i := 0; i<=iter; i++ {	// both endpoints are included
+		p := image.Point{int(cur.X), int(cur.Y)}
+		pix = append(pix, WeightedPoint{p, 1.0})
+		cur.X += dx; cur.Y += dy
+	}
+	return pix
+}
+
+// WeightedIterator reaches this many radii out from the segment
+const FootprintRadii = 3.0
+
+// every pixel within FootprintRadii * l.Radius of the segment (not the
+// infinite line) and inside bounds, each exactly once, weighted by a
+// gaussian in the distance that is 1.0 on the segment. pixels are visited
+// row by row, each row is the exact span where it crosses the capsule
+// around the segment. a zero radius line covers the pixels it passes within
+// half a pixel of, at full weight.
+//
+// the weights are peak normalized, not area normalized: they top out at 1.0
+// on the segment whatever the radius, so they don't sum to 1 and a wider
+// line soaks up more ink. callers treat a weight as how much a pixel counts
+// and rely on it staying in [0,1], LinePotential panics on anything above 1.
+func (l Line) WeightedIterator(bounds image.Rectangle) (pix []WeightedPoint) {
+	reach := math.Max(FootprintRadii * l.Radius, 0.5)
+	ymin := int(math.Ceil(math.Min(l.Left.Y, l.Right.Y) - reach))
+	ymax := int(math.Floor(math.Max(l.Left.Y, l.Right.Y) + reach))
+	ymin = max(ymin, bounds.Min.Y)
+	ymax = min(ymax, bounds.Max.Y - 1)
+	for y := ymin; y <= ymax; y++ {
+		lo, hi, ok := l.capsuleSpan(float64(y), reach)
+		if !ok { continue }
+		xmin := max(int(math.Ceil(lo)), bounds.Min.X)
+		xmax := min(int(math.Floor(hi)), bounds.Max.X - 1)
+		for x := xmin; x <= xmax; x++ {
+			d := l.SegmentDistance(Float64Point{float64(x), float64(y)})
+			if d > reach { continue }	// rounding at the very edge of the span
+			w := 1.0
+			if l.Radius > 0.0 {
+				w = math.Exp(-d * d / (2.0 * l.Radius * l.Radius))
+			}
+			pix = append(pix, WeightedPoint{image.Point{x, y}, w})
+		}
+	}
+	return pix
+}
+
+// the x interval where the row y crosses everything within r of the
+// segment. that region is convex (two discs and the band between them)
+// so the crossing is a single interval.
+func (l Line) capsuleSpan(y, r float64) (lo, hi float64, ok bool) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	grow := func(a, b float64) {
+		lo = math.Min(lo, a)
+		hi = math.Max(hi, b)
+	}
+	for _, p := range []Float64Point{l.Left, l.Right} {
+		if dy := y - p.Y; math.Abs(dy) <= r {
+			h := math.Sqrt(r * r - dy * dy)
+			grow(p.X - h, p.X + h)
+		}
+	}
+
+	// the band is a parallelogram, see where its edges cross the row
+	d := PointMinus(l.Right, l.Left)
+	n := d.L2Norm()
+	if n > 0.0 {
+		off := Float64Point{-d.Y / n * r, d.X / n * r}
+		corners := []Float64Point{
+			PointPlus(l.Left, off), PointPlus(l.Right, off),
+			PointMinus(l.Right, off), PointMinus(l.Left, off),
+		}
+		for i, a := range corners {
+			b := corners[(i + 1) % len(corners)]
+			if (a.Y - y) * (b.Y - y) > 0.0 { continue }
+			if a.Y == b.Y {
+				grow(math.Min(a.X, b.X), math.Max(a.X, b.X))
+				continue
+			}
+			x := a.X + (y - a.Y) * (b.X - a.X) / (b.Y - a.Y)
+			grow(x, x)
+		}
+	}
+	return lo, hi, lo <= hi
+}
+
+/******************************************************************************************/
+
+func (l Line) String() string {
+	return fmt.Sprintf("[%s -> %s]", l.Left.String(), l.Right.String())
+}
+
+// the smaller angle between the two lines in degrees, in [0, 90].
+// a zero length line has no direction and is at 0 degrees to everything.
+func (l Line) Angle(o Line) float64 {
+	v1 := PointMinus(o.Right, o.Left)
+	v2 := PointMinus(l.Right, l.Left)
+	n := v1.L2Norm() * v2.L2Norm()
+	if n == 0.0 {
+		return 0.0
+	}
+	// rounding can push the cosine just outside [-1,1], where Acos is NaN
+	cos := math.Max(-1.0, math.Min(1.0, DotProduct(v1, v2) / n))
+	switch d := math.Acos(cos) * 180.0 / math.Pi; {
+	case 0 <= d && d < 90.0:
+		return d
+	case 90 <= d && d <= 180.0:
+		return 180.0 - d
+	default:
+		panic(fmt.Sprintf("Line.Angle] wut?\td = %.2f\n", d))
+	}
+}
+
+func (l *Line) Rotate(theta float64) {
+	v := PointMinus(l.Right, l.Left)
+	v.Scale(0.5)
+	v.Rotate(theta)
+	m := l.Midpoint()
+	l.Left = PointMinus(m, v)
+	l.Right = PointPlus(m, v)
+}
+
+// distance from (x,y) to the infinite line through l, or to
+// the point l if it has zero length
+func (l Line) Distance(x, y float64) float64 {
+	// http://paulbourke.net/geometry/pointline/
+	sq_len := math.Pow((l.Right.X - l.Left.X), 2.0) + math.Pow((l.Right.Y - l.Left.Y), 2.0)
+	if sq_len == 0.0 {
+		return Distance(Float64Point{x, y}, l.Left)
+	}
+	u := (x - l.Left.X) * (l.Right.X - l.Left.X)
+	u += (y - l.Left.Y) * (l.Right.Y - l.Left.Y)
+	u /= sq_len
+	sx := l.Left.X + u * (l.Right.X - l.Left.X)
+	sy := l.Left.Y + u * (l.Right.Y - l.Left.Y)
+	return math.Sqrt((x-sx)*(x-sx) + (y-sy)*(y-sy))
+}
+
+func (l Line) SquaredDistance(x, y float64) float64 {
+	d := l.Distance(x, y)
+	return d * d
+}
+
+// distance from p to the closest point on the segment (not the infinite line)
+func (l Line) SegmentDistance(p Float64Point) float64 {
+	d := PointMinus(l.Right, l.Left)
+	n := DotProduct(d, d)
+	if n == 0.0 {
+		return Distance(p, l.Left)
+	}
+	t := math.Min(1.0, math.Max(0.0, DotProduct(PointMinus(p, l.Left), d) / n))
+	d.Scale(t)
+	return Distance(p, PointPlus(l.Left, d))
+}
+
+// where the infinite extensions of a and b cross, ok is false for parallel lines
+func Intersection(a, b Line) (p Float64Point, ok bool) {
+	ua, _, ok := crossParams(a, b)
+	if !ok {
+		return p, false
+	}
+	p.X = a.Left.X + ua * a.Dx()
+	p.Y = a.Left.Y + ua * a.Dy()
+	return p, true
+}
+
+// how far along a and b (0 at Left, 1 at Right) their extensions cross
+func crossParams(a, b Line) (ua, ub float64, ok bool) {
+	// http://paulbourke.net/geometry/lineline2d/
+	denom := b.Dy() * a.Dx() - b.Dx() * a.Dy()
+	if math.Abs(denom) < 1e-9 {
+		return 0.0, 0.0, false
+	}
+	ua = (b.Dx() * (a.Left.Y - b.Left.Y) - b.Dy() * (a.Left.X - b.Left.X)) / denom
+	ub = (a.Dx() * (a.Left.Y - b.Left.Y) - a.Dy() * (a.Left.X - b.Left.X)) / denom
+	return ua, ub, true
+}
@@ -0,0 +1,353 @@
+package geometry
+
+import (
+	"image"
+	"math"
+	"testing"
+	"testing/quick"
+)
+
+func seg(ax, ay, bx, by coord) Line {
+	return Line{pt(ax, ay), pt(bx, by), 1.0}
+}
+
+func TestLineDistance(t *testing.T) {
+	l := Line{Float64Point{0.0, 0.0}, Float64Point{10.0, 0.0}, 1.0}
+	if d := l.Distance(5.0, 3.0); !close(d, 3.0) {
+		t.Errorf("distance from (5,3) to x axis = %.4f, want 3", d)
+	}
+	// the line is infinite, not a segment
+	if d := l.Distance(20.0, -2.0); !close(d, 2.0) {
+		t.Errorf("distance from (20,-2) to x axis = %.4f, want 2", d)
+	}
+}
+
+func TestLineDistanceDegenerate(t *testing.T) {
+	l := Line{Float64Point{1.0, 1.0}, Float64Point{1.0, 1.0}, 1.0}
+	if d := l.Distance(4.0, 5.0); !close(d, 5.0) {
+		t.Errorf("distance from (4,5) to the point (1,1) = %.4f, want 5", d)
+	}
+}
+
+func TestLineDistanceProperties(t *testing.T) {
+	// both endpoints are on the line, and distance is never more than to an endpoint
+	f := func(ax, ay, bx, by, x, y coord) bool {
+		l := seg(ax, ay, bx, by)
+		d := l.Distance(float64(x), float64(y))
+		return !math.IsNaN(d) &&
+			l.Distance(l.Left.X, l.Left.Y) < 1e-6 &&
+			l.Distance(l.Right.X, l.Right.Y) < 1e-6 &&
+			d <= Distance(pt(x, y), l.Left) + 1e-6
+	}
+	if err := quick.Check(f, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAngle(t *testing.T) {
+	h := HorizontalLine()
+	v := VerticalLine()
+	back := Line{Float64Point{1.0, 0.0}, Float64Point{0.0, 0.0}, 0.0}
+	diag := Line{Float64Point{0.0, 0.0}, Float64Point{1.0, 1.0}, 0.0}
+	cases := []struct {
+		a, b Line
+		want float64
+	}{
+		{h, h, 0.0},
+		{h, v, 90.0},
+		{h, back, 0.0},	// anti-parallel is parallel, used to panic at exactly 180
+		{h, diag, 45.0},
+		{back, diag, 45.0},
+	}
+	for _, c := range cases {
+		if got := c.a.Angle(c.b); !close(got, c.want) {
+			t.Errorf("angle between %s and %s = %.4f, want %.1f", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestAngleRounding(t *testing.T) {
+	// the cosine of these comes out a hair above 1, which made Acos return NaN
+	a := Line{Float64Point{0.0, 0.0}, Float64Point{0.1, 0.3}, 0.0}
+	b := Line{Float64Point{0.0, 0.0}, Float64Point{0.3, 0.9}, 0.0}
+	if d := a.Angle(b); math.IsNaN(d) || d > 1e-6 {
+		t.Errorf("angle between parallel lines = %.4f", d)
+	}
+	p := Line{Float64Point{1.0, 1.0}, Float64Point{1.0, 1.0}, 0.0}
+	if d := p.Angle(a); d != 0.0 {
+		t.Errorf("angle to a zero length line = %.4f, want 0", d)
+	}
+}
+
+func TestAngleProperties(t *testing.T) {
+	f := func(ax, ay, bx, by, cx, cy, dx, dy coord) bool {
+		a, b := seg(ax, ay, bx, by), seg(cx, cy, dx, dy)
+		d := a.Angle(b)
+		return 0.0 <= d && d <= 90.0 && close(d, b.Angle(a))
+	}
+	if err := quick.Check(f, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestScaleLength(t *testing.T) {
+	l := Line{Float64Point{0.0, 0.0}, Float64Point{4.0, 0.0}, 1.0}
+	l.ScaleLength(2.0)
+	if !l.Left.Equals(Float64Point{-2.0, 0.0}) || !l.Right.Equals(Float64Point{6.0, 0.0}) {
+		t.Errorf("doubled [(0,0) -> (4,0)] = %s, want [(-2,0) -> (6,0)]", l)
+	}
+	f := func(ax, ay, bx, by, scale coord) bool {
+		l := seg(ax, ay, bx, by)
+		m := l.Midpoint()
+		n := Distance(l.Left, l.Right)
+		s := math.Abs(float64(scale)) / 100.0
+		l.ScaleLength(s)
+		return l.Midpoint().Equals(m) && math.Abs(Distance(l.Left, l.Right) - s * n) < 1e-6 * math.Max(1.0, s * n)
+	}
+	if err := quick.Check(f, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMidpoint(t *testing.T) {
+	l := Line{Float64Point{-2.0, 4.0}, Float64Point{6.0, 0.0}, 1.0}
+	if m := l.Midpoint(); !m.Equals(Float64Point{2.0, 2.0}) {
+		t.Errorf("midpoint of %s = %s, want (2,2)", l, m)
+	}
+}
+
+func TestLineRotateProperties(t *testing.T) {
+	f := func(ax, ay, bx, by, theta coord) bool {
+		l := seg(ax, ay, bx, by)
+		r := l
+		r.Rotate(float64(theta))
+		return r.Midpoint().Equals(l.Midpoint()) &&
+			close(Distance(r.Left, r.Right), Distance(l.Left, l.Right))
+	}
+	if err := quick.Check(f, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntersection(t *testing.T) {
+	a := Line{Float64Point{0.0, 1.0}, Float64Point{10.0, 1.0}, 0.0}
+	b := Line{Float64Point{3.0, 5.0}, Float64Point{3.0, 6.0}, 0.0}
+	if p, ok := Intersection(a, b); !ok || !p.Equals(Float64Point{3.0, 1.0}) {
+		t.Errorf("intersection of %s and %s = %s, %t, want (3,1)", a, b, p, ok)
+	}
+	if _, ok := Intersection(a, a); ok {
+		t.Errorf("parallel lines should not intersect")
+	}
+}
+
+func TestUnweightedIterator(t *testing.T) {
+	l := Line{Float64Point{0.0, 0.0}, Float64Point{10.0, 0.0}, 1.0}
+	pix := l.UnweightedIterator()
+	if len(pix) != 11 {
+		t.Fatalf("got %d pixels for a line 10 long, want 11", len(pix))
+	}
+	for i, wp := range pix {
+		if wp.P.X != i || wp.P.Y != 0 || wp.W != 1.0 {
+			t.Errorf("pixel %d = %v", i, wp)
+		}
+	}
+}
+
+func TestUnweightedIteratorShortLine(t *testing.T) {
+	// shorter than a pixel, used to take x from the left and y from the right
+	l := Line{Float64Point{3.0, 2.9}, Float64Point{3.2, 3.5}, 1.0}
+	pix := l.UnweightedIterator()
+	if len(pix) == 0 || pix[0].P.X != 3 || pix[0].P.Y != 2 {
+		t.Errorf("pixels for %s = %v, want to start at (3,2)", l, pix)
+	}
+	p := Line{Float64Point{3.0, 2.9}, Float64Point{3.0, 2.9}, 1.0}
+	if pix = p.UnweightedIterator(); len(pix) != 1 || pix[0].P.X != 3 || pix[0].P.Y != 2 {
+		t.Errorf("pixels for %s = %v, want just (3,2)", p, pix)
+	}
+}
+
+func TestUnweightedIteratorProperties(t *testing.T) {
+	// consecutive pixels touch, and each is near the line
+	f := func(ax, ay, bx, by coord) bool {
+		l := seg(ax, ay, bx, by)
+		pix := l.UnweightedIterator()
+		for i, wp := range pix {
+			if l.Distance(float64(wp.P.X), float64(wp.P.Y)) > 2.0 {
+				return false
+			}
+			if i > 0 {
+				prev := pix[i-1].P
+				if iabs(wp.P.X - prev.X) > 1 || iabs(wp.P.Y - prev.Y) > 1 {
+					return false
+				}
+			}
+		}
+		return len(pix) > 0
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 200}); err != nil {
+		t.Error(err)
+	}
+}
+
+// a big enough canvas that nothing gets clipped
+var everywhere = image.Rect(-1000, -1000, 1000, 1000)
+
+func TestWeightedIteratorSweepsAcross(t *testing.T) {
+	// a horizontal line drawn right to left has dx < 0, which used to pick
+	// horizontal sweeps and give a footprint one pixel tall
+	l := Line{Float64Point{20.0, 10.0}, Float64Point{0.0, 10.0}, 1.0}
+	above, below := false, false
+	for _, wp := range l.WeightedIterator(everywhere) {
+		above = above || wp.P.Y < 10
+		below = below || wp.P.Y > 10
+	}
+	if !above || !below {
+		t.Errorf("footprint of %s does not spread across the line", l)
+	}
+}
+
+func TestWeightedIteratorProperties(t *testing.T) {
+	f := func(ax, ay, bx, by coord) bool {
+		l := seg(ax / 10, ay / 10, bx / 10, by / 10)
+		for _, wp := range l.WeightedIterator(everywhere) {
+			if wp.W < 0.0 || wp.W > 1.0 {
+				return false
+			}
+			if l.SegmentDistance(NewFloat64Point(wp.P)) > FootprintRadii * l.Radius {
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 200}); err != nil {
+		t.Error(err)
+	}
+}
+
+// compares against checking every pixel in a box around the segment
+func TestWeightedIteratorExact(t *testing.T) {
+	f := func(ax, ay, bx, by coord, r uint8) bool {
+		l := seg(ax / 10, ay / 10, bx / 10, by / 10)
+		l.Radius = float64(r % 40) / 10.0	// includes 0 and radii under a pixel
+		reach := math.Max(FootprintRadii * l.Radius, 0.5)
+		bounds := image.Rect(-60, -40, 50, 70)	// clips some segments
+
+		want := make(map[image.Point]bool)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				if l.SegmentDistance(Float64Point{float64(x), float64(y)}) <= reach - 1e-9 {
+					want[image.Point{x, y}] = true
+				}
+			}
+		}
+		got := make(map[image.Point]bool)
+		for _, wp := range l.WeightedIterator(bounds) {
+			if got[wp.P] || !wp.P.In(bounds) || wp.W < 0.0 || wp.W > 1.0 {
+				return false	// twice, out of bounds, or a bad weight
+			}
+			got[wp.P] = true
+		}
+		for p := range want {
+			if !got[p] {
+				t.Logf("%s r=%.1f missed %v", l, l.Radius, p)
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 300}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWeightedIteratorWeights(t *testing.T) {
+	l := Line{Float64Point{2.0, 5.0}, Float64Point{12.0, 5.0}, 0.2}
+	for _, wp := range l.WeightedIterator(everywhere) {
+		d := l.SegmentDistance(NewFloat64Point(wp.P))
+		if want := math.Exp(-d * d / (2.0 * 0.2 * 0.2)); math.Abs(wp.W - want) > 1e-12 {
+			t.Errorf("weight %.3f at %v, want %.3f", wp.W, wp.P, want)
+		}
+		if wp.P.Y == 5 && wp.W != 1.0 {
+			t.Errorf("pixels on the line should have weight 1, got %.3f", wp.W)
+		}
+	}
+}
+
+// weights peak at 1 on the segment, even for radii well under a pixel where
+// an area normalized gaussian would put far more than 1 on it
+func TestWeightedIteratorPeak(t *testing.T) {
+	for _, r := range []float64{0.0, 0.05, 0.1, 0.25, 0.5} {
+		for _, l := range []Line{seg(2, 5, 12, 5), seg(3, 1, 3, 9), seg(1, 1, 8, 8), seg(0, 0, 7, 3)} {
+			l.Radius = r
+			peak := 0.0
+			for _, wp := range l.WeightedIterator(everywhere) {
+				peak = math.Max(peak, wp.W)
+			}
+			if peak != 1.0 {
+				t.Errorf("%s r=%.2f has max weight %g, want 1", l, r, peak)
+			}
+		}
+	}
+}
+
+func TestWeightedIteratorOutOfBounds(t *testing.T) {
+	l := Line{Float64Point{-50.0, -50.0}, Float64Point{-10.0, -20.0}, 1.0}
+	if pix := l.WeightedIterator(image.Rect(0, 0, 10, 10)); len(pix) != 0 {
+		t.Errorf("segment outside the image should have no pixels, got %d", len(pix))
+	}
+}
+
+// the sweeping implementation WeightedIterator replaced, kept to benchmark against
+func oldWeightedIterator(l Line) (pix []WeightedPoint) {
+	max_delta := 2.0 * l.Radius
+	normalizer := 1.0 / math.Sqrt(2.0 * math.Pi * l.Radius * l.Radius)
+	var p image.Point
+	cur := l.Left
+	iter := int(math.Max(math.Abs(l.Dx()), math.Abs(l.Dy()))) + 1
+	dx := l.Dx() / float64(iter); dy := l.Dy() / float64(iter)
+	for i := 0; i<iter; i++ {
+		for d := -max_delta; d < max_delta; d += 1.0 {
+			if math.Abs(dx) > math.Abs(dy) {
+				p = image.Point{int(cur.X), int(cur.Y + d)}
+			} else {
+				p = image.Point{int(cur.X + d), int(cur.Y)}
+			}
+			fp := NewFloat64Point(p)
+			dist := l.Distance(fp.X, fp.Y)
+			dist = math.Min(dist, Distance(fp, l.Left))
+			dist = math.Min(dist, Distance(fp, l.Right))
+			weight := normalizer * math.Exp(-dist * dist / (2.0 * l.Radius * l.Radius))
+			pix = append(pix, WeightedPoint{p, weight})
+		}
+		cur.X += dx; cur.Y += dy
+	}
+	return pix
+}
+
+var benchLines = []Line{
+	{Float64Point{3.0, 7.5}, Float64Point{250.0, 12.25}, 1.0},
+	{Float64Point{128.0, 2.0}, Float64Point{120.5, 254.0}, 1.0},
+	{Float64Point{10.0, 10.0}, Float64Point{240.0, 235.0}, 2.0},
+}
+
+func BenchmarkWeightedIterator(b *testing.B) {
+	bounds := image.Rect(0, 0, 256, 256)
+	for i := 0; i < b.N; i++ {
+		for _, l := range benchLines {
+			l.WeightedIterator(bounds)
+		}
+	}
+}
+
+func BenchmarkOldWeightedIterator(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, l := range benchLines {
+			oldWeightedIterator(l)
+		}
+	}
+}
+
+func iabs(a int) int {
+	if a < 0 { return -a }
+	return a
+}
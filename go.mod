@@ -0,0 +1,3 @@
+module github.com/twolfe18/sudoku
+
+go 1.22
@@ -0,0 +1,138 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+)
+
+// just enough of EXIF to find which way up a phone photo is.
+// http://www.cipa.jp/std/documents/e/DC-008-2012_E.pdf
+
+const (
+	exifOrientationTag = 0x0112
+	exifShortType = 3
+)
+
+// the orientation tag (1-8) in a JPEG's EXIF block, 1 (upright) if there
+// is no tag or the block can't be read
+func ExifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	i := 2
+	for i + 4 <= len(data) {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF:	// fill byte
+			i++
+			continue
+		case marker == 0x01 || (0xD0 <= marker && marker <= 0xD7):	// no length
+			i += 2
+			continue
+		case marker == 0xDA || marker == 0xD9:	// image data starts, EXIF comes before it
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i + 2 + length > len(data) {
+			return 1
+		}
+		seg := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+			return tiffOrientation(seg[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// looks through IFD0 of a TIFF header for the orientation tag
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd + 2 > len(tiff) {
+		return 1
+	}
+	n := int(order.Uint16(tiff[ifd:]))
+	for k := 0; k < n; k++ {
+		e := ifd + 2 + 12 * k
+		if e + 12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[e:]) != exifOrientationTag {
+			continue
+		}
+		if order.Uint16(tiff[e+2:]) != exifShortType {
+			return 1
+		}
+		o := int(order.Uint16(tiff[e+8:]))	// a single short sits at the start of the value field
+		if o < 1 || o > 8 {
+			return 1
+		}
+		return o
+	}
+	return 1
+}
+
+// where dst pixel (x,y) comes from in a w x h source stored with the given
+// EXIF orientation, so that dst is upright
+func orientedSource(orientation, x, y, w, h int) (int, int) {
+	switch orientation {
+	case 2:	// mirrored left to right
+		return w - 1 - x, y
+	case 3:	// upside down
+		return w - 1 - x, h - 1 - y
+	case 4:	// mirrored top to bottom
+		return x, h - 1 - y
+	case 5:	// transposed
+		return y, x
+	case 6:	// needs a quarter turn clockwise
+		return y, h - 1 - x
+	case 7:	// transversed
+		return w - 1 - y, h - 1 - x
+	case 8:	// needs a quarter turn counter-clockwise
+		return w - 1 - y, x
+	}
+	return x, y
+}
+
+// an upright RGBA copy of img with its origin at (0,0)
+func Orient(img image.Image, orientation int) *image.RGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if orientation < 2 || orientation > 8 {
+		out := image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.Draw(out, out.Bounds(), img, b.Min, draw.Src)
+		return out
+	}
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	out := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			sx, sy := orientedSource(orientation, x, y, w, h)
+			out.Set(x, y, img.At(b.Min.X + sx, b.Min.Y + sy))
+		}
+	}
+	return out
+}
@@ -0,0 +1,123 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// an APP1 segment holding just an orientation tag
+func exifSegment(order binary.ByteOrder, orientation uint16) []byte {
+	var tiff bytes.Buffer
+	if order == binary.LittleEndian {
+		tiff.WriteString("II")
+	} else {
+		tiff.WriteString("MM")
+	}
+	binary.Write(&tiff, order, uint16(42))
+	binary.Write(&tiff, order, uint32(8))	// IFD0 right after the header
+	binary.Write(&tiff, order, uint16(1))	// one entry
+	binary.Write(&tiff, order, uint16(exifOrientationTag))
+	binary.Write(&tiff, order, uint16(exifShortType))
+	binary.Write(&tiff, order, uint32(1))
+	binary.Write(&tiff, order, orientation)
+	binary.Write(&tiff, order, uint16(0))	// padding out the value field
+	binary.Write(&tiff, order, uint32(0))	// no next IFD
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	seg := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(payload) + 2))
+	return append(seg, payload...)
+}
+
+// a jpeg of img with an EXIF block right after the start of image marker
+func jpegWithOrientation(t *testing.T, img image.Image, order binary.ByteOrder, orientation uint16) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, exifSegment(order, orientation)...)
+	return append(out, data[2:]...)
+}
+
+func TestExifOrientation(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 8, 8))
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for o := uint16(1); o <= 8; o++ {
+			if got := ExifOrientation(jpegWithOrientation(t, img, order, o)); got != int(o) {
+				t.Errorf("%s orientation %d read as %d", order, o, got)
+			}
+		}
+	}
+
+	var plain bytes.Buffer
+	jpeg.Encode(&plain, img, nil)
+	if got := ExifOrientation(plain.Bytes()); got != 1 {
+		t.Errorf("jpeg without EXIF has orientation %d, want 1", got)
+	}
+	if got := ExifOrientation([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF}); got != 1 {
+		t.Errorf("truncated EXIF has orientation %d, want 1", got)
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// 3 wide, 2 tall, the top left pixel is marked
+	src := image.NewGray(image.Rect(10, 20, 13, 22))
+	src.Set(10, 20, color.Gray{255})
+	cases := []struct {
+		orientation int
+		w, h int
+		x, y int	// where the mark ends up
+	}{
+		{1, 3, 2, 0, 0},
+		{2, 3, 2, 2, 0},
+		{3, 3, 2, 2, 1},
+		{4, 3, 2, 0, 1},
+		{5, 2, 3, 0, 0},
+		{6, 2, 3, 1, 0},
+		{7, 2, 3, 1, 2},
+		{8, 2, 3, 0, 2},
+	}
+	for _, c := range cases {
+		out := Orient(src, c.orientation)
+		if out.Bounds() != image.Rect(0, 0, c.w, c.h) {
+			t.Errorf("orientation %d gives bounds %v, want %dx%d", c.orientation, out.Bounds(), c.w, c.h)
+			continue
+		}
+		if r, _, _, _ := out.At(c.x, c.y).RGBA(); r != 0xFFFF {
+			t.Errorf("orientation %d: mark is not at (%d, %d)", c.orientation, c.x, c.y)
+		}
+	}
+}
+
+func TestOpenImageRotatesJPEG(t *testing.T) {
+	// a phone held upright stores a landscape sensor image tagged 6
+	path := filepath.Join(t.TempDir(), "phone.jpg")
+	img := image.NewGray(image.Rect(0, 0, 32, 16))
+	if err := os.WriteFile(path, jpegWithOrientation(t, img, binary.BigEndian, 6), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := OpenImage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Bounds() != image.Rect(0, 0, 16, 32) {
+		t.Errorf("opened as %v, want 16x32", out.Bounds())
+	}
+}
+
+func TestOpenImageWebP(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "photo.webp")
+	os.WriteFile(path, []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), 0644)
+	if _, err := OpenImage(path); !errors.Is(err, ErrWebP) {
+		t.Errorf("opening a webp gave %v, want ErrWebP", err)
+	}
+}
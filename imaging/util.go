@@ -0,0 +1,109 @@
+
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"image/draw"
+	"fmt"
+	"math"
+
+	"github.com/twolfe18/sudoku/logging"
+)
+
+var log = logging.For("imaging")
+
+func DarknessAt(img image.Image, x, y int) float64 {
+	r, g, b, _ := img.At(x, y).RGBA()
+	lum := 0.21 * float64(r) + 0.71 * float64(g) + 0.07 * float64(b)
+	// TODO make this more flexible
+	//return (255.0 - lum) / 255.0		// 8 bit
+	return (65535.0 - lum) / 65535.0	// 16 bit
+}
+
+// brightness in [0,1] (1 - DarknessAt) at a point between pixel centers,
+// interpolated from the four around it. off the image the nearest edge
+// pixel is used.
+func LuminanceAt(img image.Image, x, y float64) float64 {
+	b := img.Bounds()
+	clamp := func(v, lo, hi int) int { return max(lo, min(v, hi)) }
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	fx, fy := x - float64(x0), y - float64(y0)
+	at := func(px, py int) float64 {
+		return 1.0 - DarknessAt(img, clamp(px, b.Min.X, b.Max.X - 1), clamp(py, b.Min.Y, b.Max.Y - 1))
+	}
+	top := (1.0 - fx) * at(x0, y0) + fx * at(x0 + 1, y0)
+	bottom := (1.0 - fx) * at(x0, y0 + 1) + fx * at(x0 + 1, y0 + 1)
+	return (1.0 - fy) * top + fy * bottom
+}
+
+// makes a mutable copy
+func CopyImage(img image.Image) (cpy draw.Image) {
+	b := img.Bounds()
+	cpy = image.NewRGBA(b)
+	for x := b.Min.X; x < b.Max.X; x++ {
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			cpy.Set(x, y, img.At(x, y))
+		}
+	}
+	return cpy
+}
+
+// writes img as a png, replacing anything already at outf
+func SaveImage(img image.Image, outf string) (err error) {
+	log.Debug("saving image", "path", outf)
+	writer, err := os.Create(outf)
+	if err != nil {
+		return fmt.Errorf("[SaveImage] could not open %s: %w", outf, err)
+	}
+	defer func() {
+		if cerr := writer.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("[SaveImage] could not close %s: %w", outf, cerr)
+		}
+	}()
+	if err = png.Encode(writer, img); err != nil {
+		return fmt.Errorf("[SaveImage] problem saving to %s: %w", outf, err)
+	}
+	return nil
+}
+
+// WebP has no decoder in the standard library, say so instead of "unknown format"
+var ErrWebP = errors.New("WebP is not supported, convert to PNG or JPEG first")
+
+func isWebP(data []byte) bool {
+	return len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP"
+}
+
+// reads a PNG, JPEG or GIF. JPEGs are rotated upright by their EXIF
+// orientation tag, and whatever the format, the result is RGBA with its
+// origin at (0,0) so nothing downstream has to care where it came from.
+func OpenImage(img_name string) (*image.RGBA, error) {
+	data, err := os.ReadFile(img_name)
+	if err != nil {
+		return nil, fmt.Errorf("[OpenImage] could not open %s: %w", img_name, err)
+	}
+	if isWebP(data) {
+		return nil, fmt.Errorf("[OpenImage] %s: %w", img_name, ErrWebP)
+	}
+
+	// sniff the format first so a corrupt file says which decoder gave up
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("[OpenImage] %s is not in a known image format: %w", img_name, err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("[OpenImage] error decoding %s as %s: %w", img_name, format, err)
+	}
+	orientation := 1
+	if format == "jpeg" {
+		orientation = ExifOrientation(data)
+	}
+	log.Debug("loaded image", "path", img_name, "format", format, "orientation", orientation)
+	return Orient(img, orientation), nil
+}
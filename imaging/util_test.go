@@ -0,0 +1,56 @@
+package imaging
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveImageTruncates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.png")
+	if err := os.WriteFile(path, make([]byte, 1 << 16), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveImage(image.NewGray(image.Rect(0, 0, 4, 4)), path); err != nil {
+		t.Fatal(err)
+	}
+	img, err := OpenImage(path)
+	if err != nil {
+		t.Fatalf("reopening over a longer file: %s", err)
+	}
+	if img.Bounds().Dx() != 4 {
+		t.Errorf("reopened image is %v, want 4x4", img.Bounds())
+	}
+}
+
+func TestSaveImageBadPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "out.png")
+	err := SaveImage(image.NewGray(image.Rect(0, 0, 4, 4)), path)
+	if err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("expected an error naming %s, got %v", path, err)
+	}
+}
+
+func TestOpenImageErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := OpenImage(filepath.Join(dir, "nope.png")); err == nil {
+		t.Errorf("opening a missing file should fail")
+	}
+
+	garbage := filepath.Join(dir, "garbage.png")
+	os.WriteFile(garbage, []byte("definitely not an image"), 0644)
+	if _, err := OpenImage(garbage); err == nil || !strings.Contains(err.Error(), garbage) {
+		t.Errorf("expected an error naming %s, got %v", garbage, err)
+	}
+
+	// a real png header followed by junk should blame the png decoder
+	truncated := filepath.Join(dir, "truncated.png")
+	SaveImage(image.NewGray(image.Rect(0, 0, 64, 64)), truncated)
+	buf, _ := os.ReadFile(truncated)
+	os.WriteFile(truncated, buf[:40], 0644)
+	if _, err := OpenImage(truncated); err == nil || !strings.Contains(err.Error(), "as png") {
+		t.Errorf("expected a png decoding error, got %v", err)
+	}
+}
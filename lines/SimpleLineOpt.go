@@ -0,0 +1,97 @@
+
+package lines
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/twolfe18/sudoku/geometry"
+	"github.com/twolfe18/sudoku/imaging"
+	"github.com/twolfe18/sudoku/logging"
+)
+
+var log = logging.For("lines")
+
+const (	// TODO find a consistent way to write this with stuff in edge_detectors
+	LINE_EXPANSION = 1.3
+	PROPORTION_KEEP = 0.7
+	NUM_LINES = 1	//20	// 9 cells in each dim, 10 lines in each dim X 2 dims
+	MAX_ITER = 10
+)
+
+/*func later() {
+
+	// TODO have lines *mask* out the dark parts that they are covering
+
+	// TODO first try line optimization with one line
+
+	// read the image
+	img := nil
+
+	// initialize a whole bunch of lines randomly
+	bounds := NewFloat64Rectangle(img.Bounds())
+	lines := nil//[NUM_LINES]Line
+	for i,_ := range lines {
+		lines[i] = RandomPointBetween(bounds.Min(), bounds.Max())
+	}
+
+	for iter := 0; iter < MAX_ITER; iter++ {
+
+		// optimize positions of lines
+
+		// take top 80% of the lines (by potential)
+		// i think this way is better than somehow specifying how long
+		// grid lines should be. this is less dependent on the picture (maybe)
+
+
+		// for remaining 20% randomly place lines on the grid
+		//	- maybe utilize infomation about location of top 80%
+		//	- maybe give these lines one round of optimization so they can compete	
+
+		// increase each line length
+		for _,l := range lines {
+			l.ScaleLength(LINE_EXPANSION)
+			l.ProjectInto(bounds)
+		}
+	}
+
+} */
+
+func LocalOptimizePotential(line geometry.Line, img image.Image, p Params) (bestline geometry.Line) {
+	// TODO do some kind of branch and bound
+	var newline geometry.Line
+	bestpot := math.Inf(-1)
+	best_theta := 0.0
+	for dtheta := -p.MaxDTheta; dtheta <= p.MaxDTheta; dtheta += p.DeltaDTheta {
+		for dx := -p.MaxDX; dx <= p.MaxDX; dx += p.DeltaDX {
+			for dy := -p.MaxDY; dy <= p.MaxDY; dy += p.DeltaDY {
+				newline = line
+				newline.Rotate(dtheta)
+				newline.Shift(dx, dy)
+				p := LinePotential(newline, img) - p.LambdaDTheta * dtheta - p.LambdaDX * dx - p.LambdaDY * dy
+				if p > bestpot {
+					best_theta = dtheta
+					bestpot = p
+					bestline = newline
+				}
+			}
+		}
+	}
+	log.Debug("optimized line", "dtheta", best_theta, "potential", bestpot, "from", line.String(), "to", bestline.String())
+	return bestline
+}
+
+func LinePotential(line geometry.Line, img image.Image) (pot float64) {
+	for _,wp := range line.WeightedIterator(img.Bounds()) {
+		darkness := imaging.DarknessAt(img, wp.P.X, wp.P.Y)
+		if wp.W < 0.0 || wp.W > 1.0 {
+			panic(fmt.Sprintf("[LinePotential] weight must be in [0,1]: %.2f", wp.W))
+		}
+		pot += darkness * wp.W
+	}
+	return pot
+}
+
+
+
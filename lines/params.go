@@ -0,0 +1,54 @@
+package lines
+
+import "fmt"
+
+// Params holds every tuning knob for the single line optimizer
+type Params struct {
+	LambdaDTheta float64 `json:"lambda_dtheta"`	// penalty per degree of rotation
+	DeltaDTheta float64 `json:"delta_dtheta"`	// grid step for rotations (degrees)
+	MaxDTheta float64 `json:"max_dtheta"`		// largest rotation tried (degrees)
+	LambdaDX float64 `json:"lambda_dx"`
+	DeltaDX float64 `json:"delta_dx"`
+	MaxDX float64 `json:"max_dx"`
+	LambdaDY float64 `json:"lambda_dy"`
+	DeltaDY float64 `json:"delta_dy"`
+	MaxDY float64 `json:"max_dy"`
+
+	NumLines int `json:"num_lines"`		// how many lines to place on the board
+	MaxIter int `json:"max_iter"`		// local optimization steps per line
+	LineRadius float64 `json:"line_radius"`	// std deviation of each placed line
+}
+
+func DefaultParams() (p Params) {
+	p.LambdaDTheta = 1.0
+	p.LambdaDX = 1.0
+	p.LambdaDY = 1.0
+	p.DeltaDTheta = 1
+	p.DeltaDX = 1.0
+	p.DeltaDY = 1.0
+	p.MaxDTheta = 15.0
+	p.MaxDX = 10.0
+	p.MaxDY = 10.0
+	p.NumLines = 20
+	p.MaxIter = 10
+	p.LineRadius = 1.0
+	return p
+}
+
+func (p Params) Validate() error {
+	switch {
+	case p.LambdaDTheta < 0.0 || p.LambdaDX < 0.0 || p.LambdaDY < 0.0:
+		return fmt.Errorf("line_opt: lambdas must be >= 0, got (%g, %g, %g)", p.LambdaDTheta, p.LambdaDX, p.LambdaDY)
+	case p.DeltaDTheta <= 0.0 || p.DeltaDX <= 0.0 || p.DeltaDY <= 0.0:
+		return fmt.Errorf("line_opt: deltas must be > 0, got (%g, %g, %g)", p.DeltaDTheta, p.DeltaDX, p.DeltaDY)
+	case p.MaxDTheta < 0.0 || p.MaxDX < 0.0 || p.MaxDY < 0.0:
+		return fmt.Errorf("line_opt: maxes must be >= 0, got (%g, %g, %g)", p.MaxDTheta, p.MaxDX, p.MaxDY)
+	case p.NumLines < 1:
+		return fmt.Errorf("line_opt.num_lines must be >= 1, got %d", p.NumLines)
+	case p.MaxIter < 0:
+		return fmt.Errorf("line_opt.max_iter must be >= 0, got %d", p.MaxIter)
+	case p.LineRadius <= 0.0:
+		return fmt.Errorf("line_opt.line_radius must be > 0, got %g", p.LineRadius)
+	}
+	return nil
+}
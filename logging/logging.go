@@ -0,0 +1,153 @@
+// Package logging hands out one slog.Logger per subsystem (alignment,
+// lines, imaging, ...). every subsystem is silent until its level is
+// turned down, e.g. with -log alignment=debug,imaging=info on the command line.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// above every real level, the default for all subsystems
+const LevelOff = slog.Level(1 << 10)
+
+const Usage = "log levels: LEVEL for every subsystem or SUBSYSTEM=LEVEL,... (levels: debug, info, warn, error, off)"
+
+var (
+	mu sync.Mutex
+	levels = make(map[string]*slog.LevelVar)
+	out slog.Handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+)
+
+func levelVar(subsystem string) *slog.LevelVar {
+	mu.Lock()
+	defer mu.Unlock()
+	v, ok := levels[subsystem]
+	if !ok {
+		v = new(slog.LevelVar)
+		v.Set(LevelOff)
+		levels[subsystem] = v
+	}
+	return v
+}
+
+// the logger for a subsystem, meant to be held in a package level var
+func For(subsystem string) *slog.Logger {
+	h := &handler{level: levelVar(subsystem)}
+	return slog.New(h).With("subsystem", subsystem)
+}
+
+// every subsystem asked for so far, sorted
+func Subsystems() (names []string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for k, _ := range levels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func SetLevel(subsystem string, l slog.Level) {
+	levelVar(subsystem).Set(l)
+}
+
+// where every subsystem writes, text lines on stderr by default
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	out = slog.NewTextHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})
+}
+
+func output() slog.Handler {
+	mu.Lock()
+	defer mu.Unlock()
+	return out
+}
+
+func parseLevel(s string) (slog.Level, error) {
+	if strings.EqualFold(s, "off") {
+		return LevelOff, nil
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("[logging.Parse] bad level %q", s)
+	}
+	return l, nil
+}
+
+// applies a spec like "debug" (every subsystem) or "alignment=debug,imaging=info".
+// only subsystems that have already called For can be named.
+func Parse(spec string) error {
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" { continue }
+		name, lvl, named := strings.Cut(part, "=")
+		if !named {
+			lvl = name
+		}
+		l, err := parseLevel(lvl)
+		if err != nil {
+			return err
+		}
+		if !named {
+			for _, s := range Subsystems() {
+				SetLevel(s, l)
+			}
+			continue
+		}
+		mu.Lock()
+		_, ok := levels[name]
+		mu.Unlock()
+		if !ok {
+			return fmt.Errorf("[logging.Parse] unknown subsystem %q, have %s", name, strings.Join(Subsystems(), ", "))
+		}
+		SetLevel(name, l)
+	}
+	return nil
+}
+
+// a flag.Value for Parse, use as fs.Var(logging.Flag{}, "log", logging.Usage)
+type Flag struct{}
+
+func (Flag) String() string { return "" }
+func (Flag) Set(s string) error { return Parse(s) }
+
+// filters on its subsystem's level, then passes records on to whatever
+// output is current. attrs and groups are replayed onto that output since
+// it may have changed since the logger was made.
+type handler struct {
+	level *slog.LevelVar
+	ops []func(slog.Handler) slog.Handler
+}
+
+func (h *handler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.level.Level()
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	o := output()
+	for _, op := range h.ops {
+		o = op(o)
+	}
+	return o.Handle(ctx, r)
+}
+
+func (h *handler) with(op func(slog.Handler) slog.Handler) *handler {
+	ops := append(append([]func(slog.Handler) slog.Handler(nil), h.ops...), op)
+	return &handler{level: h.level, ops: ops}
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(o slog.Handler) slog.Handler { return o.WithAttrs(attrs) })
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return h.with(func(o slog.Handler) slog.Handler { return o.WithGroup(name) })
+}
@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSilentByDefault(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	l := For("test.silent")
+	l.Error("should not show")
+	if buf.Len() != 0 {
+		t.Errorf("default level wrote %q", buf.String())
+	}
+}
+
+func TestParse(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	a := For("test.a").With("iteration", 3)
+	b := For("test.b")
+	if err := Parse("test.a=debug, test.b=warn"); err != nil {
+		t.Fatal(err)
+	}
+	a.Debug("from a", "potential", 1.5)
+	b.Info("from b")
+	b.Warn("warning from b")
+	out := buf.String()
+	if !strings.Contains(out, "from a") || !strings.Contains(out, "subsystem=test.a") || !strings.Contains(out, "iteration=3") || !strings.Contains(out, "potential=1.5") {
+		t.Errorf("missing debug line from a: %q", out)
+	}
+	if strings.Contains(out, "msg=\"from b\"") || !strings.Contains(out, "warning from b") {
+		t.Errorf("b should only log warnings: %q", out)
+	}
+
+	if err := Parse("off"); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	a.Error("quiet")
+	if buf.Len() != 0 {
+		t.Errorf("off should silence everything, got %q", buf.String())
+	}
+	SetLevel("test.a", slog.LevelInfo)
+	a.Info("loud")
+	if !strings.Contains(buf.String(), "loud") {
+		t.Errorf("SetLevel did not take")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	For("test.c")
+	for _, spec := range []string{"nosuchsubsystem=debug", "test.c=loud", "shouty"} {
+		if err := Parse(spec); err == nil {
+			t.Errorf("expected an error parsing %q", spec)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package solver
+
+import "github.com/twolfe18/sudoku/board"
+
+// the textbook solver: fill the first blank with each digit that fits and
+// recurse. simple enough to trust, and what the faster solvers are tested
+// against, but slow on hard puzzles.
+type Backtrack struct{}
+
+func (Backtrack) Solve(b board.Board, limit int) (sols []board.Board) {
+	var search func(i int) bool
+	// true when there are enough solutions
+	search = func(i int) bool {
+		for i < board.NumCells && b[i] != 0 {
+			i++
+		}
+		if i == board.NumCells {
+			sols = append(sols, b)
+			return limit > 0 && len(sols) >= limit
+		}
+		r, c := i / board.Size, i % board.Size
+		for v := 1; v <= board.Size; v++ {
+			if !fits(&b, r, c, v) { continue }
+			b[i] = v
+			if search(i + 1) { return true }
+		}
+		b[i] = 0
+		return false
+	}
+	search(0)
+	return sols
+}
+
+// whether v can go at r, c without repeating a digit
+func fits(b *board.Board, r, c, v int) bool {
+	r0, c0 := r / board.BoxSize * board.BoxSize, c / board.BoxSize * board.BoxSize
+	for k := 0; k < board.Size; k++ {
+		if b.At(r, k) == v || b.At(k, c) == v {
+			return false
+		}
+		if b.At(r0 + k / board.BoxSize, c0 + k % board.BoxSize) == v {
+			return false
+		}
+	}
+	return true
+}
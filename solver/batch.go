@@ -0,0 +1,161 @@
+package solver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/twolfe18/sudoku/board"
+)
+
+// how one puzzle of a Batch went
+type Result struct {
+	Index int	// counting from 0, skipped lines don't count
+	Puzzle string	// the line as read
+	Solution board.Board	// only set when Status is Solved
+	Status Status
+	Reason string	// why it's Invalid
+	Elapsed time.Duration
+}
+
+func solveLine(s Solver, index int, line string) (res Result) {
+	res = Result{Index: index, Puzzle: line, Status: Invalid}
+	start := time.Now()
+	defer func() { res.Elapsed = time.Since(start) }()
+	b, err := board.Parse([]byte(line), board.Line)
+	if err != nil {
+		res.Reason = err.Error()
+		return res
+	}
+	if err = b.Validate(); err != nil {
+		res.Reason = err.Error()
+		return res
+	}
+	res.Solution, res.Status = Check(s, b)
+	if res.Status == Invalid {
+		res.Reason = "no solution"
+	}
+	return res
+}
+
+// one line per puzzle, tab separated: the solution (or the puzzle if it
+// has none or more than one), the status, the time spent, and for invalid
+// puzzles the reason
+func (r Result) String() string {
+	out := r.Puzzle
+	if r.Status == Solved {
+		line, _ := r.Solution.Marshal(board.Line)
+		out = strings.TrimSpace(string(line))
+	}
+	s := fmt.Sprintf("%s\t%s\t%.3fms", out, r.Status, float64(r.Elapsed.Microseconds()) / 1000.0)
+	if r.Reason != "" {
+		s += "\t" + r.Reason
+	}
+	return s
+}
+
+type Summary struct {
+	Puzzles, Solved, Invalid, Multiple int
+	Total, Max time.Duration	// solving time summed over puzzles, and the slowest
+	Wall time.Duration	// start to finish
+}
+
+func (s *Summary) add(r Result) {
+	s.Puzzles++
+	switch r.Status {
+	case Solved:
+		s.Solved++
+	case Multiple:
+		s.Multiple++
+	default:
+		s.Invalid++
+	}
+	s.Total += r.Elapsed
+	s.Max = max(s.Max, r.Elapsed)
+}
+
+func (s Summary) Print(w io.Writer) {
+	fmt.Fprintf(w, "%d puzzles: %d solved, %d invalid, %d multiple\n", s.Puzzles, s.Solved, s.Invalid, s.Multiple)
+	if s.Puzzles == 0 {
+		return
+	}
+	fmt.Fprintf(w, "solve time: total %s, mean %s, max %s\n", s.Total, s.Total / time.Duration(s.Puzzles), s.Max)
+	fmt.Fprintf(w, "wall time: %s, %.0f puzzles/s\n", s.Wall, float64(s.Puzzles) / s.Wall.Seconds())
+}
+
+// how many puzzles each worker may be ahead of the writer, so one slow
+// puzzle doesn't leave everything after it waiting in memory
+const batchWindow = 64
+
+// reads puzzles one per line (board.Line) from r, solves them on workers
+// goroutines and writes a Result line for each to w in the order they were
+// read. blank lines and lines starting with # are skipped. s is shared by
+// the workers.
+func Batch(r io.Reader, w io.Writer, s Solver, workers int) (sum Summary, err error) {
+	start := time.Now()
+	workers = max(workers, 1)
+	type job struct {
+		index int
+		line string
+	}
+	jobs := make(chan job)
+	results := make(chan Result)
+	window := make(chan struct{}, batchWindow * workers)
+
+	var read_err error
+	go func() {
+		defer close(jobs)
+		sc := bufio.NewScanner(r)
+		for i := 0; sc.Scan(); {
+			l := strings.TrimSpace(sc.Text())
+			if l == "" || strings.HasPrefix(l, "#") { continue }
+			window <- struct{}{}
+			jobs <- job{i, l}
+			i++
+		}
+		read_err = sc.Err()
+	}()
+
+	var wg sync.WaitGroup
+	for k := 0; k < workers; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				results <- solveLine(s, j.index, j.line)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// results come back in any order, hold them until it's their turn
+	bw := bufio.NewWriter(w)
+	pending := make(map[int]Result)
+	next := 0
+	for res := range results {
+		pending[res.Index] = res
+		for {
+			p, ok := pending[next]
+			if !ok { break }
+			delete(pending, next)
+			fmt.Fprintln(bw, p)
+			sum.add(p)
+			<-window
+			next++
+		}
+	}
+	sum.Wall = time.Since(start)
+	if read_err != nil {
+		return sum, fmt.Errorf("[Batch] problem reading puzzles: %w", read_err)
+	}
+	if err = bw.Flush(); err != nil {
+		return sum, fmt.Errorf("[Batch] problem writing solutions: %w", err)
+	}
+	return sum, nil
+}
@@ -0,0 +1,94 @@
+package solver
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/twolfe18/sudoku/board"
+)
+
+// solves the nearly full puzzles slowly, so later ones finish first
+type slowSolver struct {
+	Solver
+}
+
+func (s slowSolver) Solve(b board.Board, limit int) []board.Board {
+	if b.Givens() > 70 {
+		time.Sleep(20 * time.Millisecond)
+	}
+	return s.Solver.Solve(b, limit)
+}
+
+func TestBatch(t *testing.T) {
+	in := strings.Join([]string{
+		"# a comment, then a blank line",
+		"",
+		twoWays,
+		classic,
+		noWay,
+		"53..7....6..195",
+		classic,
+	}, "\n")
+	var out bytes.Buffer
+	sum, err := Batch(strings.NewReader(in), &out, slowSolver{Backtrack{}}, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected a line per puzzle, got\n%s", out.String())
+	}
+	want := []struct{ board, status string }{
+		{twoWays, "multiple"},
+		{classicSolution, "solved"},
+		{noWay, "invalid"},
+		{"53..7....6..195", "invalid"},
+		{classicSolution, "solved"},
+	}
+	for i, w := range want {
+		fields := strings.Split(lines[i], "\t")
+		if len(fields) < 3 || fields[0] != w.board || fields[1] != w.status {
+			t.Errorf("line %d is %q, want %s %s", i, lines[i], w.board, w.status)
+		}
+		if w.status == "invalid" && len(fields) != 4 {
+			t.Errorf("line %d should say why it's invalid: %q", i, lines[i])
+		}
+	}
+	if sum.Puzzles != 5 || sum.Solved != 2 || sum.Invalid != 2 || sum.Multiple != 1 {
+		t.Errorf("summary %+v", sum)
+	}
+	if sum.Max < 20 * time.Millisecond || sum.Total < sum.Max || sum.Wall <= 0 {
+		t.Errorf("timings %+v", sum)
+	}
+	var buf bytes.Buffer
+	sum.Print(&buf)
+	if !strings.HasPrefix(buf.String(), "5 puzzles: 2 solved, 2 invalid, 1 multiple\n") {
+		t.Errorf("summary printed as\n%s", buf.String())
+	}
+}
+
+func TestBatchKeepsOrder(t *testing.T) {
+	// more puzzles than fit in the window, alternating slow and fast
+	var in strings.Builder
+	for i := 0; i < 3 * batchWindow; i++ {
+		p := classic
+		if i % 7 == 0 { p = twoWays }
+		in.WriteString(p + "\n")
+	}
+	var out bytes.Buffer
+	sum, err := Batch(strings.NewReader(in.String()), &out, slowSolver{Backtrack{}}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, l := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		status := strings.Split(l, "\t")[1]
+		if (i % 7 == 0) != (status == "multiple") {
+			t.Fatalf("line %d out of order: %q", i, l)
+		}
+	}
+	if sum.Puzzles != 3 * batchWindow {
+		t.Errorf("%d puzzles", sum.Puzzles)
+	}
+}
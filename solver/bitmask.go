@@ -0,0 +1,166 @@
+package solver
+
+import (
+	"math/bits"
+
+	"github.com/twolfe18/sudoku/board"
+)
+
+// the fast one, for solving puzzles by the thousand. each row, column and
+// box keeps a 9 bit mask of the digits it has, so a cell's candidates are
+// one OR away. forced moves (naked and hidden singles) are made first,
+// then the blank with the fewest candidates, and the search is a loop over
+// an explicit stack rather than recursion.
+type Bitmask struct{}
+
+const allDigits = 1 << board.Size - 1
+
+// where each cell is, worked out once
+var cellRow, cellCol, cellBox [board.NumCells]uint8
+
+func init() {
+	for i := range cellRow {
+		r, c := i / board.Size, i % board.Size
+		cellRow[i], cellCol[i] = uint8(r), uint8(c)
+		cellBox[i] = uint8(r / board.BoxSize * board.BoxSize + c / board.BoxSize)
+	}
+}
+
+// a search in progress. blanks[:depth] have been filled, in order, and
+// tried[d] holds the digits left to try at blanks[d].
+type bitmaskSearch struct {
+	b board.Board
+	rows, cols, boxes [board.Size]uint16
+	blanks [board.NumCells]uint8
+	tried [board.NumCells]uint16
+	n int	// number of blanks
+}
+
+func (s *bitmaskSearch) candidates(i uint8) uint16 {
+	return ^(s.rows[cellRow[i]] | s.cols[cellCol[i]] | s.boxes[cellBox[i]]) & allDigits
+}
+
+func (s *bitmaskSearch) toggle(i uint8, bit uint16) {
+	s.rows[cellRow[i]] ^= bit
+	s.cols[cellCol[i]] ^= bit
+	s.boxes[cellBox[i]] ^= bit
+}
+
+// false if the givens clash
+func (s *bitmaskSearch) init(b board.Board) bool {
+	s.b = b
+	for i, v := range b {
+		if v == 0 {
+			s.blanks[s.n] = uint8(i)
+			s.n++
+			continue
+		}
+		bit := uint16(1) << (v - 1)
+		if s.candidates(uint8(i)) & bit == 0 {
+			return false
+		}
+		s.toggle(uint8(i), bit)
+	}
+	return true
+}
+
+// moves the blank to fill next to blanks[depth] and returns the digits to
+// try there, 0 if the search is stuck. a blank with no or one candidate is
+// taken straight away, then a digit that only fits one blank of a row,
+// column or box (a hidden single), and otherwise the blank with the fewest
+// candidates.
+func (s *bitmaskSearch) pick(depth int) uint16 {
+	var cands [board.NumCells]uint16
+	best, best_n := depth, board.Size + 1
+	for k := depth; k < s.n; k++ {
+		c := s.candidates(s.blanks[k])
+		n := bits.OnesCount16(c)
+		if n <= 1 {
+			s.blanks[depth], s.blanks[k] = s.blanks[k], s.blanks[depth]
+			return c
+		}
+		cands[k] = c
+		if n < best_n {
+			best, best_n = k, n
+		}
+	}
+
+	// which digits turn up as a candidate in one blank of each unit, and
+	// which in more. rows are units 0-8, columns 9-17 and boxes 18-26.
+	var once, twice [3 * board.Size]uint16
+	for k := depth; k < s.n; k++ {
+		i, c := s.blanks[k], cands[k]
+		for _, u := range [3]uint8{cellRow[i], board.Size + cellCol[i], 2 * board.Size + cellBox[i]} {
+			twice[u] |= once[u] & c
+			once[u] |= c
+		}
+	}
+	for u := range once {
+		var placed uint16
+		switch {
+		case u < board.Size:
+			placed = s.rows[u]
+		case u < 2 * board.Size:
+			placed = s.cols[u - board.Size]
+		default:
+			placed = s.boxes[u - 2 * board.Size]
+		}
+		if (once[u] | placed) != allDigits {
+			return 0	// a digit with nowhere to go
+		}
+		hidden := once[u] &^ twice[u]
+		if hidden == 0 { continue }
+		bit := hidden & -hidden
+		for k := depth; k < s.n; k++ {
+			i := s.blanks[k]
+			in := [3]int{int(cellRow[i]), board.Size + int(cellCol[i]), 2 * board.Size + int(cellBox[i])}
+			if cands[k] & bit != 0 && (in[0] == u || in[1] == u || in[2] == u) {
+				s.blanks[depth], s.blanks[k] = s.blanks[k], s.blanks[depth]
+				return bit
+			}
+		}
+	}
+
+	c := cands[best]
+	s.blanks[depth], s.blanks[best] = s.blanks[best], s.blanks[depth]
+	return c
+}
+
+func (Bitmask) Solve(b board.Board, limit int) (sols []board.Board) {
+	var s bitmaskSearch
+	if !s.init(b) {
+		return nil
+	}
+	depth := 0
+	descend := true
+	for {
+		if descend {
+			if depth == s.n {
+				sols = append(sols, s.b)
+				if limit > 0 && len(sols) >= limit { return sols }
+				descend = false
+				continue
+			}
+			s.tried[depth] = s.pick(depth)
+		} else {
+			// back up to the last blank that has digits left to try
+			if depth == 0 { return sols }
+			depth--
+			i := s.blanks[depth]
+			s.toggle(i, 1 << (s.b[i] - 1))
+			s.b[i] = 0
+		}
+		cands := s.tried[depth]
+		if cands == 0 {
+			descend = false
+			continue
+		}
+		bit := cands & -cands
+		s.tried[depth] = cands ^ bit
+		i := s.blanks[depth]
+		s.toggle(i, bit)
+		s.b[i] = bits.TrailingZeros16(bit) + 1
+		depth++
+		descend = true
+	}
+}
@@ -0,0 +1,94 @@
+package solver
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/twolfe18/sudoku/board"
+)
+
+const corpus = "testdata/hard.txt"
+
+func loadCorpus(t testing.TB) (puzzles []board.Board) {
+	f, err := os.Open(corpus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		l := strings.TrimSpace(sc.Text())
+		if l == "" || strings.HasPrefix(l, "#") { continue }
+		puzzles = append(puzzles, parse(t, l))
+	}
+	if err = sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return puzzles
+}
+
+// the fast solvers agree on every puzzle in the corpus, and each has
+// exactly one solution
+func TestCorpus(t *testing.T) {
+	for i, p := range loadCorpus(t) {
+		sol, st := Check(Bitmask{}, p)
+		if st != Solved {
+			t.Errorf("puzzle %d: %s", i, st)
+			continue
+		}
+		if !sol.Solved() {
+			t.Errorf("puzzle %d: not a solution:\n%s", i, sol)
+		}
+		if d := (DLX{}).Solve(p, 2); len(d) != 1 || d[0] != sol {
+			t.Errorf("puzzle %d: dlx found %d solutions, or a different one", i, len(d))
+		}
+	}
+}
+
+func TestBitmaskClash(t *testing.T) {
+	dup := parse(t, classic)
+	dup.Set(8, 0, 5)
+	if sols := (Bitmask{}).Solve(dup, 0); len(sols) != 0 {
+		t.Errorf("repeated given: %d solutions", len(sols))
+	}
+	full := parse(t, classicSolution)
+	if sols := (Bitmask{}).Solve(full, 0); len(sols) != 1 || sols[0] != full {
+		t.Errorf("a solved board is its own solution, got %v", sols)
+	}
+}
+
+// solves the corpus far enough to know each answer is unique, like Check
+func benchmarkCorpus(b *testing.B, s Solver) {
+	puzzles := loadCorpus(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, p := range puzzles {
+			s.Solve(p, 2)
+		}
+	}
+	b.ReportMetric(float64(b.N * len(puzzles)) / b.Elapsed().Seconds(), "puzzles/s")
+}
+
+func BenchmarkBitmask(b *testing.B) { benchmarkCorpus(b, Bitmask{}) }
+
+func BenchmarkDLX(b *testing.B) { benchmarkCorpus(b, DLX{}) }
+
+// the whole pipeline, parsing and ordering included, on every core
+func BenchmarkBatchBitmask(b *testing.B) {
+	data, err := os.ReadFile(corpus)
+	if err != nil {
+		b.Fatal(err)
+	}
+	n := len(loadCorpus(b))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err = Batch(strings.NewReader(string(data)), io.Discard, Bitmask{}, runtime.NumCPU()); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(b.N * n) / b.Elapsed().Seconds(), "puzzles/s")
+}
@@ -0,0 +1,210 @@
+package solver
+
+import "github.com/twolfe18/sudoku/board"
+
+// an exact cover problem, solved with Knuth's dancing links (Algorithm X):
+// pick a set of rows so that every column has exactly one 1 among them.
+// sudoku is one of these, and so are most of its variants, which only add
+// columns (diagonals, say) or change which rows there are.
+//
+// the 1s are kept in circular doubly linked lists, across each row and
+// down each column. covering a column unlinks it and every row that hits
+// it, and uncovering puts them back in reverse order, so backtracking
+// costs nothing but the pointer updates.
+type Matrix struct {
+	// node 0 is the root, 1..cols are the column headers, then the 1s.
+	// all of them are indices into these.
+	l, r, u, d []int
+	col []int	// header of each node
+	row []int	// row of each node, -1 for headers
+	size []int	// 1s left in each column, by header
+	first []int	// a node of each row
+	covered []bool	// by header
+	chosen []int	// rows picked with Choose, they start every solution
+}
+
+func NewMatrix(cols int) *Matrix {
+	m := &Matrix{covered: make([]bool, cols + 1)}
+	for i := 0; i <= cols; i++ {
+		m.l = append(m.l, (i + cols) % (cols + 1))
+		m.r = append(m.r, (i + 1) % (cols + 1))
+		m.u = append(m.u, i)
+		m.d = append(m.d, i)
+		m.col = append(m.col, i)
+		m.row = append(m.row, -1)
+		m.size = append(m.size, 0)
+	}
+	return m
+}
+
+func (m *Matrix) Rows() int { return len(m.first) }
+
+// adds a row with 1s in the given columns (counting from 0) and returns its
+// index. rows can't be added once Choose or Search has been called.
+func (m *Matrix) AddRow(cols ...int) int {
+	row := len(m.first)
+	first := len(m.l)
+	m.first = append(m.first, first)
+	for k, c := range cols {
+		h, n := c + 1, len(m.l)
+		// at the bottom of the column
+		m.u = append(m.u, m.u[h])
+		m.d = append(m.d, h)
+		m.d[m.u[h]] = n
+		m.u[h] = n
+		// at the end of the row
+		m.l = append(m.l, n - 1)
+		m.r = append(m.r, first)
+		if k == 0 {
+			m.l[n] = n
+		} else {
+			m.r[n - 1] = n
+			m.l[first] = n
+		}
+		m.col = append(m.col, h)
+		m.row = append(m.row, row)
+		m.size[h]++
+	}
+	return row
+}
+
+func (m *Matrix) cover(h int) {
+	m.covered[h] = true
+	m.r[m.l[h]], m.l[m.r[h]] = m.r[h], m.l[h]
+	for i := m.d[h]; i != h; i = m.d[i] {
+		for j := m.r[i]; j != i; j = m.r[j] {
+			m.d[m.u[j]], m.u[m.d[j]] = m.d[j], m.u[j]
+			m.size[m.col[j]]--
+		}
+	}
+}
+
+func (m *Matrix) uncover(h int) {
+	for i := m.u[h]; i != h; i = m.u[i] {
+		for j := m.l[i]; j != i; j = m.l[j] {
+			m.size[m.col[j]]++
+			m.d[m.u[j]], m.u[m.d[j]] = j, j
+		}
+	}
+	m.r[m.l[h]], m.l[m.r[h]] = h, h
+	m.covered[h] = false
+}
+
+// puts row in every solution, like a given in a puzzle. false, and no
+// change, if it clashes with a row already chosen.
+func (m *Matrix) Choose(row int) bool {
+	n := m.first[row]
+	for j := n; ; {
+		if m.covered[m.col[j]] { return false }
+		if j = m.r[j]; j == n { break }
+	}
+	for j := n; ; {
+		m.cover(m.col[j])
+		if j = m.r[j]; j == n { break }
+	}
+	m.chosen = append(m.chosen, row)
+	return true
+}
+
+// calls fn with the rows of each solution in turn, the chosen ones first,
+// until fn returns false or there are no more. the slice is reused, copy
+// it to keep it. the matrix is back as it was (but for Choose) afterwards.
+func (m *Matrix) Search(fn func(rows []int) bool) {
+	rows := append([]int(nil), m.chosen...)
+	var search func() bool
+	// false once fn has had enough
+	search = func() bool {
+		if m.r[0] == 0 {
+			return fn(rows)
+		}
+		// the column with the fewest 1s left keeps the tree narrow
+		h := m.r[0]
+		for c := m.r[h]; c != 0; c = m.r[c] {
+			if m.size[c] < m.size[h] { h = c }
+		}
+		if m.size[h] == 0 {
+			return true
+		}
+		m.cover(h)
+		more := true
+		for i := m.d[h]; more && i != h; i = m.d[i] {
+			rows = append(rows, m.row[i])
+			for j := m.r[i]; j != i; j = m.r[j] {
+				m.cover(m.col[j])
+			}
+			more = search()
+			for j := m.l[i]; j != i; j = m.l[j] {
+				m.uncover(m.col[j])
+			}
+			rows = rows[:len(rows) - 1]
+		}
+		m.uncover(h)
+		return more
+	}
+	search()
+}
+
+// sudoku as exact cover: a row for each digit in each cell, and a column
+// for each cell, and for each digit in each row, column and box, as they
+// all need exactly one
+type DLX struct{}
+
+const (
+	cellCols = 0
+	rowCols = board.NumCells
+	colCols = 2 * board.NumCells
+	boxCols = 3 * board.NumCells
+	sudokuCols = 4 * board.NumCells
+)
+
+// row r * Size + v - 1 of the matrix is digit v in cell r
+func sudokuMatrix(b board.Board) (m *Matrix, ok bool) {
+	m = NewMatrix(sudokuCols)
+	for i := 0; i < board.NumCells; i++ {
+		r, c := i / board.Size, i % board.Size
+		box := r / board.BoxSize * board.BoxSize + c / board.BoxSize
+		for v := 0; v < board.Size; v++ {
+			m.AddRow(cellCols + i, rowCols + r * board.Size + v, colCols + c * board.Size + v, boxCols + box * board.Size + v)
+		}
+	}
+	for i, v := range b {
+		if v != 0 && !m.Choose(i * board.Size + v - 1) {
+			return m, false
+		}
+	}
+	return m, true
+}
+
+// calls fn with each solution of b in turn, until fn returns false or
+// they run out. nothing is found past the ones fn sees, so stopping early
+// is cheap. clashing givens have no solutions.
+func (DLX) Each(b board.Board, fn func(board.Board) bool) {
+	m, ok := sudokuMatrix(b)
+	if !ok { return }
+	m.Search(func(rows []int) bool {
+		var sol board.Board
+		for _, row := range rows {
+			sol[row / board.Size] = row % board.Size + 1
+		}
+		return fn(sol)
+	})
+}
+
+// the number of solutions of b, stopping at limit if it is > 0
+func (DLX) Count(b board.Board, limit int) (n int) {
+	m, ok := sudokuMatrix(b)
+	if !ok { return 0 }
+	m.Search(func([]int) bool {
+		n++
+		return limit <= 0 || n < limit
+	})
+	return n
+}
+
+func (s DLX) Solve(b board.Board, limit int) (sols []board.Board) {
+	s.Each(b, func(sol board.Board) bool {
+		sols = append(sols, sol)
+		return limit <= 0 || len(sols) < limit
+	})
+	return sols
+}
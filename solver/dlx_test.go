@@ -0,0 +1,90 @@
+package solver
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/twolfe18/sudoku/board"
+)
+
+// the example from Knuth's paper, columns A to G
+func TestMatrix(t *testing.T) {
+	m := NewMatrix(7)
+	for _, cols := range [][]int{{2, 4, 5}, {0, 3, 6}, {1, 2, 5}, {0, 3}, {1, 6}, {3, 4, 6}} {
+		m.AddRow(cols...)
+	}
+	var found [][]int
+	m.Search(func(rows []int) bool {
+		found = append(found, append([]int(nil), rows...))
+		return true
+	})
+	if len(found) != 1 {
+		t.Fatalf("expected one solution, got %v", found)
+	}
+	sort.Ints(found[0])
+	if want := []int{0, 3, 4}; !equal(found[0], want) {
+		t.Errorf("got rows %v, want %v", found[0], want)
+	}
+
+	// the links are all put back, searching again finds the same
+	n := 0
+	m.Search(func([]int) bool { n++; return true })
+	if n != 1 {
+		t.Errorf("second search found %d", n)
+	}
+
+	if !m.Choose(1) {
+		t.Fatalf("nothing else is chosen yet")
+	}
+	if m.Choose(3) {
+		t.Errorf("rows 1 and 3 share column A, both can't be chosen")
+	}
+	m.Search(func(rows []int) bool {
+		t.Errorf("no solutions with row 1, got %v", rows)
+		return true
+	})
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) { return false }
+	for i := range a {
+		if a[i] != b[i] { return false }
+	}
+	return true
+}
+
+func TestDLXCount(t *testing.T) {
+	s := DLX{}
+	if n := s.Count(parse(t, classic), 0); n != 1 {
+		t.Errorf("classic: %d solutions", n)
+	}
+	if n := s.Count(parse(t, twoWays), 0); n != 2 {
+		t.Errorf("two ways: %d solutions", n)
+	}
+	if n := s.Count(parse(t, noWay), 0); n != 0 {
+		t.Errorf("no way: %d solutions", n)
+	}
+	if n := s.Count(board.Board{}, 1000); n != 1000 {
+		t.Errorf("blank board with limit 1000: %d", n)
+	}
+	dup := parse(t, classic)
+	dup.Set(0, 2, 5)
+	if n := s.Count(dup, 0); n != 0 {
+		t.Errorf("repeated given: %d solutions", n)
+	}
+}
+
+func TestDLXEach(t *testing.T) {
+	// stops as soon as asked, and the same board keeps giving the same ones
+	var first []board.Board
+	DLX{}.Each(board.Board{}, func(b board.Board) bool {
+		first = append(first, b)
+		return len(first) < 5
+	})
+	if len(first) != 5 {
+		t.Fatalf("asked to stop after 5, got %d", len(first))
+	}
+	if again := (DLX{}).Solve(board.Board{}, 5); len(again) != 5 || again[4] != first[4] {
+		t.Errorf("Solve and Each disagree")
+	}
+}
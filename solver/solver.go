@@ -0,0 +1,63 @@
+// Package solver fills in boards. every backend implements Solver, so they
+// can be swapped on the command line and checked against each other.
+package solver
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/twolfe18/sudoku/board"
+)
+
+type Solver interface {
+	// up to limit solutions of b, all of them if limit <= 0. b must be
+	// valid (board.Validate), what happens otherwise is up to the solver.
+	// Batch calls this from many goroutines at once.
+	Solve(b board.Board, limit int) []board.Board
+}
+
+// the solvers by name, for -solver flags
+var Solvers = map[string]func() Solver{
+	"backtrack": func() Solver { return Backtrack{} },
+	"bitmask": func() Solver { return Bitmask{} },
+	"dlx": func() Solver { return DLX{} },
+}
+
+func Names() (names []string) {
+	for n := range Solvers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func New(name string) (Solver, error) {
+	if f, ok := Solvers[name]; ok {
+		return f(), nil
+	}
+	return nil, fmt.Errorf("[solver.New] unknown solver %q, expected one of %v", name, Names())
+}
+
+// what Check makes of a puzzle
+type Status string
+
+const (
+	Solved Status = "solved"	// exactly one solution
+	Invalid Status = "invalid"	// repeated givens, or no solution
+	Multiple Status = "multiple"	// more than one solution
+)
+
+// solves b far enough to tell if it is a proper puzzle. the solution is
+// only filled in when there is exactly one.
+func Check(s Solver, b board.Board) (board.Board, Status) {
+	if b.Validate() != nil {
+		return b, Invalid
+	}
+	switch sols := s.Solve(b, 2); len(sols) {
+	case 0:
+		return b, Invalid
+	case 1:
+		return sols[0], Solved
+	}
+	return b, Multiple
+}
@@ -0,0 +1,94 @@
+package solver
+
+import (
+	"testing"
+
+	"github.com/twolfe18/sudoku/board"
+)
+
+const (
+	classic = "53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79"
+	classicSolution = "534678912672195348198342567859761423426853791713924856961537284287419635345286179"
+	// the solution with an unavoidable rectangle blanked out, the 1s and 3s
+	// can go either way round
+	twoWays = "53467891267219534819834256785976.42.42685.79.713924856961537284287419635345286179"
+	// consistent givens but no way to finish: (1, 3) can't be anything
+	noWay = "12.......34.......56.........7........8........9................................."
+)
+
+func parse(t testing.TB, s string) board.Board {
+	b, err := board.Parse([]byte(s), board.Line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// every registered solver gets the same tests
+func TestSolvers(t *testing.T) {
+	for _, name := range Names() {
+		s, err := New(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(name, func(t *testing.T) { testSolver(t, s) })
+	}
+	if _, err := New("guess"); err == nil {
+		t.Errorf("unknown solver should be an error")
+	}
+}
+
+func testSolver(t *testing.T, s Solver) {
+	sols := s.Solve(parse(t, classic), 0)
+	if len(sols) != 1 || sols[0] != parse(t, classicSolution) {
+		t.Fatalf("classic puzzle: got %v", sols)
+	}
+
+	// a blank board has billions, limit has to stop it
+	sols = s.Solve(board.Board{}, 3)
+	if len(sols) != 3 {
+		t.Fatalf("%d solutions of a blank board with limit 3", len(sols))
+	}
+	for i, b := range sols {
+		if !b.Solved() {
+			t.Errorf("solution %d isn't a solved board:\n%s", i, b)
+		}
+		for j := 0; j < i; j++ {
+			if sols[j] == b { t.Errorf("solutions %d and %d are the same", i, j) }
+		}
+	}
+
+	// every solution has to keep the givens
+	given := parse(t, twoWays)
+	sols = s.Solve(given, 0)
+	if len(sols) < 2 {
+		t.Fatalf("%d solutions of a puzzle with several", len(sols))
+	}
+	for _, b := range sols {
+		for i, v := range given {
+			if v != 0 && b[i] != v { t.Fatalf("solution changed a given:\n%s", b) }
+		}
+	}
+
+	if sols = s.Solve(parse(t, noWay), 0); len(sols) != 0 {
+		t.Errorf("unsolvable puzzle came back with %d solutions", len(sols))
+	}
+}
+
+func TestCheck(t *testing.T) {
+	s := Backtrack{}
+	if b, st := Check(s, parse(t, classic)); st != Solved || b != parse(t, classicSolution) {
+		t.Errorf("classic: %s\n%s", st, b)
+	}
+	if _, st := Check(s, parse(t, twoWays)); st != Multiple {
+		t.Errorf("two ways: %s", st)
+	}
+	if _, st := Check(s, parse(t, noWay)); st != Invalid {
+		t.Errorf("no way: %s", st)
+	}
+	dup := parse(t, classic)
+	dup.Set(0, 2, 5)
+	if _, st := Check(s, dup); st != Invalid {
+		t.Errorf("repeated given: %s", st)
+	}
+}
@@ -0,0 +1,234 @@
+package synth
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+
+	"github.com/twolfe18/sudoku/alignment"
+	"github.com/twolfe18/sudoku/board"
+	"github.com/twolfe18/sudoku/drawing"
+	"github.com/twolfe18/sudoku/geometry"
+)
+
+// renders sudoku boards the way a camera might see them, with the
+// alignment.Annotation that goes with each one. the board is drawn in its own unit
+// square and mapped into the image by a homography, so the corners in
+// the annotation are exact.
+
+type SynthParams struct {
+	Size int		// output is Size x Size pixels
+	BoardFraction float64	// board side as a fraction of Size
+	LineThickness float64	// pixels, lines between cells
+	BoxLineThickness float64	// pixels, lines between 3x3 boxes and the border
+	Font string		// key into drawing.Fonts
+	DigitHeight float64	// as a fraction of the cell
+	Rotation float64	// max rotation in degrees, drawn uniformly per image
+	Perspective float64	// max corner displacement as a fraction of the board side
+	Blur int		// box blur radius in pixels, 0 for none
+	Noise float64		// std deviation of gaussian pixel noise, intensities are in [0,1]
+	Gradient float64	// darkening across the image from a random direction, in [0,1]
+	Clutter int		// random strokes drawn around the board
+}
+
+func DefaultSynthParams() (p SynthParams) {
+	p.Size = 256
+	p.BoardFraction = 0.8
+	p.LineThickness = 1.0
+	p.BoxLineThickness = 3.0
+	p.Font = "5x7"
+	p.DigitHeight = 0.6
+	p.Rotation = 5.0
+	p.Perspective = 0.05
+	p.Blur = 1
+	p.Noise = 0.03
+	p.Gradient = 0.3
+	p.Clutter = 5
+	return p
+}
+
+func (p SynthParams) Validate() error {
+	switch {
+	case p.Size < 16:
+		return fmt.Errorf("synth: size must be >= 16, got %d", p.Size)
+	case p.BoardFraction <= 0.0 || p.BoardFraction > 1.0:
+		return fmt.Errorf("synth: board fraction must be in (0,1], got %g", p.BoardFraction)
+	case p.LineThickness < 0.0 || p.BoxLineThickness < 0.0:
+		return fmt.Errorf("synth: line thickness must be >= 0, got %g and %g", p.LineThickness, p.BoxLineThickness)
+	case drawing.Fonts[p.Font] == nil:
+		return fmt.Errorf("synth: unknown font %q", p.Font)
+	case p.DigitHeight <= 0.0 || p.DigitHeight > 1.0:
+		return fmt.Errorf("synth: digit height must be in (0,1], got %g", p.DigitHeight)
+	case p.Rotation < 0.0 || p.Perspective < 0.0:
+		return fmt.Errorf("synth: rotation and perspective must be >= 0, got %g and %g", p.Rotation, p.Perspective)
+	case p.Blur < 0 || p.Noise < 0.0 || p.Clutter < 0:
+		return fmt.Errorf("synth: blur, noise and clutter must be >= 0")
+	case p.Gradient < 0.0 || p.Gradient > 1.0:
+		return fmt.Errorf("synth: gradient must be in [0,1], got %g", p.Gradient)
+	}
+	return nil
+}
+
+// 81 characters, row major, '.' or '0' for blanks (board.Line)
+func ParsePuzzleLine(s string) (cells []int, err error) {
+	b, err := board.Parse([]byte(s), board.Line)
+	if err != nil {
+		return nil, err
+	}
+	return b.Cells(), nil
+}
+
+type stroke struct {
+	line geometry.Line
+	halfwidth, intensity float64
+}
+
+func Render(cells []int, p SynthParams, rng *rand.Rand) (img *image.Gray, a alignment.Annotation, err error) {
+	if err = p.Validate(); err != nil {
+		return nil, a, err
+	}
+	if len(cells) != alignment.SudokuGridDimension * alignment.SudokuGridDimension {
+		return nil, a, fmt.Errorf("[Render] expected %d cells, got %d", alignment.SudokuGridDimension * alignment.SudokuGridDimension, len(cells))
+	}
+	for _, v := range cells {
+		if v < 0 || v > alignment.SudokuGridDimension {
+			return nil, a, fmt.Errorf("[Render] bad cell value %d", v)
+		}
+	}
+	a.Cells = make([]int, len(cells))
+	copy(a.Cells, cells)
+
+	// where the board corners land in the image
+	size := float64(p.Size)
+	side := p.BoardFraction * size
+	theta := (rng.Float64() * 2.0 - 1.0) * p.Rotation * math.Pi / 180.0
+	unit := [4]geometry.Float64Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}}
+	for i, u := range unit {
+		c := geometry.Float64Point{X: (u.X - 0.5) * side, Y: (u.Y - 0.5) * side}
+		c.Rotate(theta)
+		c.Shift(size / 2.0, size / 2.0)
+		c.Shift((rng.Float64() * 2.0 - 1.0) * p.Perspective * side, (rng.Float64() * 2.0 - 1.0) * p.Perspective * side)
+		a.Corners[i] = c
+	}
+	h, ok := geometry.HomographyFromQuads(unit, a.Corners)
+	inv, ok2 := h.Inverse()
+	if !ok || !ok2 {
+		return nil, a, fmt.Errorf("[Render] degenerate board corners %s", a.Corners)
+	}
+
+	clutter := make([]stroke, p.Clutter)
+	for i := range clutter {
+		lo := geometry.Float64Point{X: rng.Float64() * size, Y: rng.Float64() * size}
+		hi := geometry.Float64Point{X: rng.Float64() * size, Y: rng.Float64() * size}
+		clutter[i] = stroke{geometry.Line{Left: lo, Right: hi}, 0.5 + rng.Float64() * 3.0, 0.2 + rng.Float64() * 0.4}
+	}
+	light_dir := rng.Float64() * 2.0 * math.Pi
+
+	// intensity in [0,1], 2x2 supersampled around the pixel center, which
+	// is at integer coordinates like everywhere else
+	font := drawing.Fonts[p.Font]
+	buf := make([]float64, p.Size * p.Size)
+	for y := 0; y < p.Size; y++ {
+		for x := 0; x < p.Size; x++ {
+			v := 0.0
+			for sy := -0.25; sy < 0.5; sy += 0.5 {
+				for sx := -0.25; sx < 0.5; sx += 0.5 {
+					pt := geometry.Float64Point{X: float64(x) + sx, Y: float64(y) + sy}
+					v += 0.25 * synthIntensity(pt, inv.Apply(pt), cells, clutter, side, font, p)
+				}
+			}
+			// lighting ramp from 0 to 1 across the image
+			t := (math.Cos(light_dir) * (float64(x) - size / 2.0) + math.Sin(light_dir) * (float64(y) - size / 2.0)) / size + 0.5
+			buf[y * p.Size + x] = v * (1.0 - p.Gradient * math.Min(1.0, math.Max(0.0, t)))
+		}
+	}
+
+	for i := 0; i < 3; i++ {	// three box blurs look close to a gaussian
+		boxBlur(buf, p.Size, p.Blur)
+	}
+
+	img = image.NewGray(image.Rect(0, 0, p.Size, p.Size))
+	for y := 0; y < p.Size; y++ {
+		for x := 0; x < p.Size; x++ {
+			v := buf[y * p.Size + x] + rng.NormFloat64() * p.Noise
+			v = math.Min(1.0, math.Max(0.0, v))
+			img.Set(x, y, color.Gray{uint8(v * 255.0 + 0.5)})
+		}
+	}
+	return img, a, nil
+}
+
+// pt is in image pixels, b is the same point in board coordinates
+func synthIntensity(pt, b geometry.Float64Point, cells []int, clutter []stroke, side float64, font *drawing.BitmapFont, p SynthParams) float64 {
+	const paper, ink, background = 1.0, 0.1, 0.75
+	const margin = 0.05	// paper around the board, as a fraction of the board
+
+	if b.X < -margin || b.Y < -margin || b.X > 1.0 + margin || b.Y > 1.0 + margin {
+		v := background
+		for _, s := range clutter {
+			if s.line.SegmentDistance(pt) < s.halfwidth {
+				v = math.Min(v, s.intensity)
+			}
+		}
+		return v
+	}
+
+	n := float64(alignment.SudokuGridDimension)
+	for k := 0; k <= alignment.SudokuGridDimension; k++ {
+		half := p.LineThickness / 2.0 / side
+		if k % 3 == 0 { half = p.BoxLineThickness / 2.0 / side }
+		g := float64(k) / n
+		in_u := b.Y >= -half && b.Y <= 1.0 + half
+		in_v := b.X >= -half && b.X <= 1.0 + half
+		if (in_u && math.Abs(b.X - g) <= half) || (in_v && math.Abs(b.Y - g) <= half) {
+			return ink
+		}
+	}
+
+	if b.X < 0.0 || b.Y < 0.0 || b.X >= 1.0 || b.Y >= 1.0 {
+		return paper
+	}
+	r, c := int(b.Y * n), int(b.X * n)
+	d := cells[r * alignment.SudokuGridDimension + c]
+	if d == 0 {
+		return paper
+	}
+	gh := p.DigitHeight
+	gw := gh * float64(font.Width) / float64(font.Height)
+	gu := (b.X * n - float64(c) - 0.5) / gw + 0.5
+	gv := (b.Y * n - float64(r) - 0.5) / gh + 0.5
+	if font.Ink(rune('0' + d), gu, gv) {
+		return ink
+	}
+	return paper
+}
+
+// in place, separable, edges are clamped
+func boxBlur(buf []float64, size, radius int) {
+	if radius <= 0 {
+		return
+	}
+	tmp := make([]float64, size)
+	at := func(i int) int { return int(math.Min(float64(size - 1), math.Max(0.0, float64(i)))) }
+	w := float64(2 * radius + 1)
+	for pass := 0; pass < 2; pass++ {
+		for a := 0; a < size; a++ {
+			idx := func(b int) int {
+				if pass == 0 { return a * size + b }	// along rows
+				return b * size + a			// along columns
+			}
+			for b := 0; b < size; b++ {
+				s := 0.0
+				for k := -radius; k <= radius; k++ {
+					s += buf[idx(at(b + k))]
+				}
+				tmp[b] = s / w
+			}
+			for b := 0; b < size; b++ {
+				buf[idx(b)] = tmp[b]
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package synth
+
+import (
+	"image"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/twolfe18/sudoku/alignment"
+	"github.com/twolfe18/sudoku/geometry"
+)
+
+// no noise, blur, shading or clutter, just the board
+func cleanParams() SynthParams {
+	p := DefaultSynthParams()
+	p.Blur = 0
+	p.Noise = 0.0
+	p.Gradient = 0.0
+	p.Clutter = 0
+	return p
+}
+
+func render(t *testing.T, p SynthParams, seed int64) (*image.Gray, alignment.Annotation) {
+	img, a, err := Render(make([]int, 81), p, rand.New(rand.NewSource(seed)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = a.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return img, a
+}
+
+// across an inner box line, the ink weighted by how dark it is should be
+// centered on where the annotation says the line is. pixel x covers
+// [x - 0.5, x + 0.5], getting that wrong moves it by half a pixel. one row
+// is only as good as the 2x2 supersampling, so this averages over many.
+func TestRenderLinesMatchAnnotation(t *testing.T) {
+	unit := [4]geometry.Float64Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}}
+	for seed := int64(1); seed <= 5; seed++ {
+		img, a := render(t, cleanParams(), seed)
+		h, _ := geometry.HomographyFromQuads(unit, a.Corners)
+		inv, _ := h.Inverse()
+		for _, u := range []float64{1.0 / 3.0, 2.0 / 3.0} {
+			total, rows := 0.0, 0
+			for v := 0.05; v < 0.95; v += 0.01 {
+				y := int(math.Round(h.Apply(geometry.Float64Point{X: u, Y: v}).Y))
+				// where the line crosses row y, by bisection
+				lo, hi := 0.0, float64(img.Bounds().Dx())
+				for k := 0; k < 50; k++ {
+					mid := (lo + hi) / 2.0
+					if inv.Apply(geometry.Float64Point{X: mid, Y: float64(y)}).X < u { lo = mid } else { hi = mid }
+				}
+				sum, weighted := 0.0, 0.0
+				for x := int(math.Round(lo)) - 5; x <= int(math.Round(lo)) + 5; x++ {
+					ink := 1.0 - float64(img.GrayAt(x, y).Y) / 255.0
+					sum += ink
+					weighted += ink * float64(x)
+				}
+				total += weighted / sum - lo
+				rows++
+			}
+			if off := total / float64(rows); math.Abs(off) > 0.1 {
+				t.Errorf("seed %d: line u=%.2f drawn %.2f pixels off on average", seed, u, off)
+			}
+		}
+	}
+}
+
+func TestRenderCorners(t *testing.T) {
+	img, a := render(t, cleanParams(), 7)
+	// every corner is on the border, which is ink
+	for i, c := range a.Corners {
+		if g := img.GrayAt(int(math.Round(c.X)), int(math.Round(c.Y))).Y; g > 128 {
+			t.Errorf("corner %d at %s is light (%d)", i, c, g)
+		}
+	}
+	// and deterministic given the rng
+	again, b := render(t, cleanParams(), 7)
+	if b.Corners != a.Corners || string(again.Pix) != string(img.Pix) {
+		t.Errorf("the same seed rendered differently")
+	}
+}
+
+func TestRenderRejects(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	if _, _, err := Render(make([]int, 80), cleanParams(), rng); err == nil {
+		t.Errorf("80 cells should fail")
+	}
+	bad := make([]int, 81)
+	bad[3] = 10
+	if _, _, err := Render(bad, cleanParams(), rng); err == nil {
+		t.Errorf("a 10 should fail")
+	}
+	p := cleanParams()
+	p.Size = 4
+	if _, _, err := Render(make([]int, 81), p, rng); err == nil {
+		t.Errorf("a tiny image should fail")
+	}
+}